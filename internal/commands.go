@@ -1,10 +1,15 @@
 package internal
 
 import (
+	"database/sql"
+	"fmt"
 	"os"
+	"path/filepath"
 	"strconv"
+	"strings"
 
 	"github.com/marcos-brito/sozza/internal/connector"
+	"github.com/marcos-brito/sozza/internal/source"
 	log "github.com/sirupsen/logrus"
 	"github.com/urfave/cli/v2"
 )
@@ -27,18 +32,57 @@ func Create(ctx *cli.Context) error {
 }
 
 func Insert(ctx *cli.Context) error {
-	db := connector.PickConnector(ctx.String("dbname")).Connect(ctx.String("url"))
+	database := connector.PickConnector(ctx.String("dbname"))
+	db := database.Connect(ctx.String("url"))
 
-	mapping, err := ReadMappingFromFile(ctx.String("mapping"))
+	params, err := parseParams(ctx.StringSlice("set"))
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	inserter, err := newInserter(db, mapping, ctx.String("csv"))
+	mapping, err := ReadMappingFromFile(ctx.String("mapping"), params)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if ctx.String("layout") != "" {
+		mapping.Layout, err = ReadLayoutFromFile(ctx.String("layout"))
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if ctx.IsSet("seed") {
+		mapping.Seed = ctx.Int64("seed")
+	}
+
+	inputs, err := parseInputs(ctx.StringSlice("input"))
 	if err != nil {
 		log.Fatal(err)
 	}
 
+	options := source.Options{
+		Format:    ctx.String("format"),
+		Encoding:  ctx.String("encoding"),
+		HasHeader: !ctx.Bool("no-header"),
+		Columns:   mapping.Columns,
+		Sheet:     ctx.String("sheet"),
+		Layout:    mapping.Layout,
+	}
+
+	sources := map[string]source.Source{}
+	for name, paths := range inputs {
+		input, err := source.OpenAll(paths, options)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer input.Close()
+
+		sources[name] = input
+	}
+
+	inserter := newInserter(db, database.Dialect(), mapping, sources)
+
 	numberOfLines, err := strconv.Atoi(ctx.String("number-of-lines"))
 	if err != nil {
 		log.Fatalf("Invalid number of lines %s", ctx.String("number-of-lines"))
@@ -51,3 +95,93 @@ func Insert(ctx *cli.Context) error {
 
 	return nil
 }
+
+// Inserts rows made only of literals, generators, fake data and references,
+// without reading any input
+func Generate(ctx *cli.Context) error {
+	database := connector.PickConnector(ctx.String("dbname"))
+	db := database.Connect(ctx.String("url"))
+
+	params, err := parseParams(ctx.StringSlice("set"))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	mapping, err := ReadMappingFromFile(ctx.String("mapping"), params)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if ctx.IsSet("seed") {
+		mapping.Seed = ctx.Int64("seed")
+	}
+
+	rows := ctx.Int("rows")
+	if rows < 0 {
+		log.Fatalf("Invalid number of rows %d", rows)
+	}
+
+	err = generateRows(db, database.Dialect(), mapping, rows)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return nil
+}
+
+// Each source of the mapping gets the same number of rows
+func generateRows(db *sql.DB, dialect string, mapping *Mapping, rows int) error {
+	sources := map[string]source.Source{}
+	for _, item := range mapping.Tables {
+		sources[item.Source] = source.NewBlank(rows)
+	}
+
+	return newInserter(db, dialect, mapping, sources).Insert(rows)
+}
+
+// Params are given as key=value
+func parseParams(values []string) (map[string]string, error) {
+	params := map[string]string{}
+
+	for _, value := range values {
+		key, param, found := strings.Cut(value, "=")
+
+		if !found || key == "" {
+			return nil, fmt.Errorf("Invalid param %s. It should be key=value", value)
+		}
+
+		params[key] = param
+	}
+
+	return params, nil
+}
+
+// Inputs are given as name=path, or just the path for tables that do not
+// declare a source. Paths may be globs, in which case all matching files are
+// read as the same input
+func parseInputs(values []string) (map[string][]string, error) {
+	inputs := map[string][]string{}
+
+	for _, value := range values {
+		name, pattern, found := strings.Cut(value, "=")
+
+		if !found || strings.ContainsRune(name, filepath.Separator) {
+			name, pattern = "", value
+		}
+
+		paths, err := filepath.Glob(pattern)
+
+		if err != nil {
+			return nil, fmt.Errorf("Invalid input path %s: %s", pattern, err)
+		}
+
+		// Let the source complain about files that do not exist
+		if len(paths) == 0 {
+			paths = []string{pattern}
+		}
+
+		inputs[name] = append(inputs[name], paths...)
+	}
+
+	return inputs, nil
+}
@@ -0,0 +1,146 @@
+package internal
+
+import (
+	"os"
+	"path"
+	"reflect"
+	"testing"
+)
+
+func TestParseInputs(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"users-1.csv", "users-2.csv", "houses.csv"} {
+		if err := os.WriteFile(path.Join(dir, name), []byte{}, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		values   []string
+		expected map[string][]string
+	}{
+		{
+			[]string{"data.csv"},
+			map[string][]string{"": {"data.csv"}},
+		},
+		{
+			[]string{"users=users.csv", "houses=houses.csv"},
+			map[string][]string{"users": {"users.csv"}, "houses": {"houses.csv"}},
+		},
+		{
+			[]string{"users=" + path.Join(dir, "users-*.csv"), "houses=" + path.Join(dir, "houses.csv")},
+			map[string][]string{
+				"users":  {path.Join(dir, "users-1.csv"), path.Join(dir, "users-2.csv")},
+				"houses": {path.Join(dir, "houses.csv")},
+			},
+		},
+		{
+			[]string{"users=a.csv", "users=b.csv"},
+			map[string][]string{"users": {"a.csv", "b.csv"}},
+		},
+		{
+			[]string{"./dir=weird/file.csv"},
+			map[string][]string{"": {"./dir=weird/file.csv"}},
+		},
+	}
+
+	for _, tt := range tests {
+		got, err := parseInputs(tt.values)
+
+		if err != nil {
+			t.Error(err)
+		}
+
+		if !reflect.DeepEqual(tt.expected, got) {
+			t.Errorf("Expected %v, but got %v: %v", tt.expected, got, tt.values)
+		}
+	}
+}
+
+func TestParseParams(t *testing.T) {
+	tests := []struct {
+		values     []string
+		expected   map[string]string
+		shouldFail bool
+	}{
+		{[]string{"tenant=42", "batch=a=b"}, map[string]string{"tenant": "42", "batch": "a=b"}, false},
+		{[]string{"tags=red,blue", "filter=a=b,c=d"}, map[string]string{"tags": "red,blue", "filter": "a=b,c=d"}, false},
+		{[]string{"empty="}, map[string]string{"empty": ""}, false},
+		{[]string{"tenant"}, nil, true},
+		{[]string{"=42"}, nil, true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseParams(tt.values)
+
+		if (err != nil) != tt.shouldFail {
+			t.Errorf("Expected failure to be %v, but got %v", tt.shouldFail, err)
+			continue
+		}
+
+		if !tt.shouldFail && !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("Expected %v, but got %v", tt.expected, got)
+		}
+	}
+}
+
+func TestGenerateRows(t *testing.T) {
+	generate := func(seed string, rows int) [][]any {
+		database := testDatabases(t)[0]
+		execAll(
+			t,
+			database.database,
+			"DROP TABLE IF EXISTS users",
+			"DROP TABLE IF EXISTS house",
+			"CREATE TABLE house (code INTEGER PRIMARY KEY, color TEXT)",
+			"CREATE TABLE users (id "+database.idType+", name TEXT, age INTEGER, house_code INTEGER)",
+		)
+
+		mapping, err := ReadMapping([]byte(`
+seed: `+seed+`
+house:
+  insertions:
+    - code: seq(100)
+      color: fake('word')
+users:
+  insertions:
+    - name: fake('name')
+      age: fake('int', 18, 90)
+      house_code: __house__
+`), nil)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := generateRows(database.database, database.dialect, mapping, rows); err != nil {
+			t.Fatal(err)
+		}
+
+		return queryAll(t, database.database, "SELECT h.code, h.color, u.name, u.age FROM users u JOIN house h ON h.code = u.house_code ORDER BY u.id")
+	}
+
+	first := generate("42", 5)
+
+	if len(first) != 5 {
+		t.Fatalf("Expected 5 rows, but got %v", first)
+	}
+
+	for idx, row := range first {
+		if row[0] != int64(100+idx) {
+			t.Errorf("Expected the code %d, but got %v", 100+idx, row[0])
+		}
+	}
+
+	if again := generate("42", 5); !reflect.DeepEqual(first, again) {
+		t.Errorf("Expected the same seed to give %v, but got %v", first, again)
+	}
+
+	if other := generate("7", 5); reflect.DeepEqual(first, other) {
+		t.Errorf("Expected another seed to give other rows, but got %v", other)
+	}
+
+	if none := generate("42", 0); len(none) != 0 {
+		t.Errorf("Expected no rows, but got %v", none)
+	}
+}
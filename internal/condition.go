@@ -0,0 +1,253 @@
+package internal
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Compares two values with ==, !=, <, <=, > or >=. Numbers are compared as
+// numbers when both sides are numeric, everything else as text
+type Comparison struct {
+	operator string
+	left     Insertable
+	right    Insertable
+}
+
+// Combines conditions with && or ||. Operands are only evaluated when needed
+type Logical struct {
+	operator string
+	operands []Insertable
+}
+
+type Not struct {
+	operand Insertable
+}
+
+var comparisons = []string{"==", "!=", "<=", ">=", "<", ">"}
+
+// Parses the condition used to decide whether an insertion happens, like
+// Age >= 18 && trim(HouseColor) != ""
+func (p *Parser) parseCondition(value string) (Insertable, error) {
+	tokens, err := tokenize(value)
+
+	if err != nil {
+		return nil, err
+	}
+
+	expression := &expressionParser{parser: p, tokens: tokens}
+	condition, err := expression.parseOr()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !expression.done() {
+		return nil, fmt.Errorf("Unexpected %s in %s", expression.peek().text, value)
+	}
+
+	return condition, nil
+}
+
+// Filters have a location of their own, so their fakes don't depend on the
+// last field parsed
+func (p *Parser) parseFilter(source string, value string) (Insertable, error) {
+	p.location, p.fakes = "filter:"+source, 0
+
+	return p.parseCondition(value)
+}
+
+func (e *expressionParser) parseOr() (Insertable, error) {
+	return e.parseLogical("||", e.parseAnd)
+}
+
+func (e *expressionParser) parseAnd() (Insertable, error) {
+	return e.parseLogical("&&", e.parseUnary)
+}
+
+func (e *expressionParser) parseLogical(operator string, parseOperand func() (Insertable, error)) (Insertable, error) {
+	first, err := parseOperand()
+
+	if err != nil {
+		return nil, err
+	}
+
+	operands := []Insertable{first}
+	for !e.done() && e.peek().kind == symbolToken && e.peek().text == operator {
+		e.next()
+		operand, err := parseOperand()
+
+		if err != nil {
+			return nil, err
+		}
+
+		operands = append(operands, operand)
+	}
+
+	if len(operands) == 1 {
+		return first, nil
+	}
+
+	return &Logical{operator: operator, operands: operands}, nil
+}
+
+func (e *expressionParser) parseUnary() (Insertable, error) {
+	if current := e.peek(); current.kind == symbolToken && current.text == "!" {
+		e.next()
+		operand, err := e.parseUnary()
+
+		if err != nil {
+			return nil, err
+		}
+
+		return &Not{operand: operand}, nil
+	}
+
+	return e.parseComparison()
+}
+
+func (e *expressionParser) parseComparison() (Insertable, error) {
+	var left Insertable
+	var err error
+
+	if current := e.peek(); current.kind == symbolToken && current.text == "(" {
+		e.next()
+		if left, err = e.parseOr(); err != nil {
+			return nil, err
+		}
+
+		if err = e.expect(")"); err != nil {
+			return nil, err
+		}
+	} else if left, err = e.parseOperand(); err != nil {
+		return nil, err
+	}
+
+	current := e.peek()
+	if e.done() || current.kind != symbolToken || !isComparison(current.text) {
+		return left, nil
+	}
+
+	e.next()
+	right, err := e.parseOperand()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &Comparison{operator: current.text, left: left, right: right}, nil
+}
+
+func isComparison(operator string) bool {
+	for _, comparison := range comparisons {
+		if operator == comparison {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (c *Comparison) generateValue(context InsertContext) (any, error) {
+	left, err := c.left.generateValue(context)
+
+	if err != nil {
+		return nil, err
+	}
+
+	right, err := c.right.generateValue(context)
+
+	if err != nil {
+		return nil, err
+	}
+
+	result := compareValues(left, right)
+
+	switch c.operator {
+	case "==":
+		return result == 0, nil
+	case "!=":
+		return result != 0, nil
+	case "<":
+		return result < 0, nil
+	case "<=":
+		return result <= 0, nil
+	case ">":
+		return result > 0, nil
+	case ">=":
+		return result >= 0, nil
+	default:
+		return nil, fmt.Errorf("Unknown operator %s", c.operator)
+	}
+}
+
+// NULL is only equal to NULL and the empty string, since that's what empty
+// csv cells are
+func compareValues(left any, right any) int {
+	if left == nil || right == nil {
+		return strings.Compare(toString(left), toString(right))
+	}
+
+	leftNumber, leftErr := strconv.ParseFloat(strings.TrimSpace(toString(left)), 64)
+	rightNumber, rightErr := strconv.ParseFloat(strings.TrimSpace(toString(right)), 64)
+
+	if leftErr == nil && rightErr == nil {
+		switch {
+		case leftNumber < rightNumber:
+			return -1
+		case leftNumber > rightNumber:
+			return 1
+		default:
+			return 0
+		}
+	}
+
+	return strings.Compare(toString(left), toString(right))
+}
+
+func (l *Logical) generateValue(context InsertContext) (any, error) {
+	for _, operand := range l.operands {
+		value, err := operand.generateValue(context)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if isTruthy(value) == (l.operator == "||") {
+			return l.operator == "||", nil
+		}
+	}
+
+	return l.operator == "&&", nil
+}
+
+func (n *Not) generateValue(context InsertContext) (any, error) {
+	value, err := n.operand.generateValue(context)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return !isTruthy(value), nil
+}
+
+// NULL, empty strings, false and zero are false. Text is only false when it
+// is empty or the word false, so values like F or 0 in a text column are
+// still true. A column alone, like when: HouseColor, is true when it has
+// some other value
+func isTruthy(value any) bool {
+	switch v := value.(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	case int64:
+		return v != 0
+	case float64:
+		return v != 0
+	default:
+		text := toString(v)
+
+		return text != "" && !strings.EqualFold(text, "false")
+	}
+}
@@ -0,0 +1,98 @@
+package internal
+
+import (
+	"testing"
+)
+
+func TestCondition(t *testing.T) {
+	context := InsertContext{
+		record: mapRecord{
+			"HouseColor": "red",
+			"Pet":        "",
+			"Age":        "9",
+			"Active":     "false",
+			"Nickname":   nil,
+		},
+	}
+
+	tests := []struct {
+		input      string
+		expected   bool
+		shouldFail bool
+	}{
+		{"HouseColor", true, false},
+		{"Pet", false, false},
+		{"!Pet", true, false},
+		{"Nickname == ''", true, false},
+		{"HouseColor != ''", true, false},
+		{"trim(Pet) != ''", false, false},
+		{"Age > 10", false, false},
+		{"Age >= 9 && HouseColor == 'red'", true, false},
+		{"Age < 5 || upper(HouseColor) == 'RED'", true, false},
+		{"!(Age < 5 || Pet)", true, false},
+		{"Active == 'false'", true, false},
+		{"Age == 9.0", true, false},
+		{"HouseColor < 'yellow'", true, false},
+		{"Missing == 'x'", false, true},
+		{"Age >", false, true},
+		{"(Age > 1", false, true},
+		{"Age > 1 Pet", false, true},
+	}
+
+	for _, tt := range tests {
+		condition, err := NewParser(nil).parseCondition(tt.input)
+
+		var value any
+		if err == nil {
+			value, err = condition.generateValue(context)
+		}
+
+		if err != nil {
+			if !tt.shouldFail {
+				t.Errorf("Expected %s to be evaluated, but got %s", tt.input, err)
+			}
+
+			continue
+		}
+
+		if tt.shouldFail {
+			t.Errorf("Expected %s to fail, but got %v", tt.input, value)
+		}
+
+		if isTruthy(value) != tt.expected {
+			t.Errorf("Expected %v, but got %v for %s", tt.expected, value, tt.input)
+		}
+	}
+}
+
+func TestIsTruthy(t *testing.T) {
+	tests := []struct {
+		value    any
+		expected bool
+	}{
+		{nil, false},
+		{"", false},
+		{"false", false},
+		{"FALSE", false},
+		{"0", true},
+		{"no", true},
+		{"n", true},
+		{"f", true},
+		{"F", true},
+		{"true", true},
+		{"yes", true},
+		{"1", true},
+		{"red", true},
+		{int64(0), false},
+		{int64(3), true},
+		{0.0, false},
+		{false, false},
+		{true, true},
+	}
+
+	for _, tt := range tests {
+		if got := isTruthy(tt.value); got != tt.expected {
+			t.Errorf("Expected %v to be %v, but got %v", tt.value, tt.expected, got)
+		}
+	}
+}
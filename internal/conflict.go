@@ -0,0 +1,89 @@
+package internal
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// What happens when a row violates a unique constraint of its table
+type Conflict struct {
+	// Columns of the unique constraint
+	On []string `yaml:"on"`
+	// error, ignore or update
+	Do string `yaml:"do"`
+	// Columns updated with the new values. All but the ones in on by default
+	Update []string `yaml:"update"`
+}
+
+var conflictActions = []string{"error", "ignore", "update"}
+
+func (c *Conflict) validate(fields map[string]Insertable) error {
+	if !slices.Contains(conflictActions, c.Do) {
+		return fmt.Errorf("Conflicts must be handled with one of %s, but got %s", strings.Join(conflictActions, ", "), c.Do)
+	}
+
+	if c.Do == "error" {
+		return nil
+	}
+
+	if len(c.On) == 0 {
+		return fmt.Errorf("The columns of the conflict must be given with on")
+	}
+
+	for _, column := range append(slices.Clone(c.On), c.Update...) {
+		if _, ok := fields[column]; !ok {
+			return fmt.Errorf("The conflict uses %s, but it is not inserted", column)
+		}
+	}
+
+	return nil
+}
+
+// Whether the id of the row must be read back, since the insert may not
+// have created one
+func (c *Conflict) handled() bool {
+	return c != nil && c.Do != "error"
+}
+
+func (c *Conflict) updatedColumns(order []string) []string {
+	if len(c.Update) > 0 {
+		return c.Update
+	}
+
+	columns := []string{}
+	for _, column := range order {
+		if !slices.Contains(c.On, column) {
+			columns = append(columns, column)
+		}
+	}
+
+	return columns
+}
+
+// Renders the insert of a table with conflicts handled with ON CONFLICT, which
+// both postgres and sqlite understand
+func (c *Conflict) insertStatement(table string, order []string) string {
+	placeholders := strings.Repeat("?, ", len(order))
+	placeholders, _ = strings.CutSuffix(placeholders, ", ")
+	updated := c.updatedColumns(order)
+	action := "DO NOTHING"
+
+	if c.Do == "update" && len(updated) > 0 {
+		assignments := []string{}
+		for _, column := range updated {
+			assignments = append(assignments, fmt.Sprintf("%s = excluded.%s", column, column))
+		}
+
+		action = "DO UPDATE SET " + strings.Join(assignments, ", ")
+	}
+
+	return fmt.Sprintf(
+		"INSERT INTO %s (%s) VALUES (%s) ON CONFLICT (%s) %s",
+		table,
+		strings.Join(order, ", "),
+		placeholders,
+		strings.Join(c.On, ", "),
+		action,
+	)
+}
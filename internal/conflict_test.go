@@ -0,0 +1,60 @@
+package internal
+
+import (
+	"testing"
+)
+
+func TestConflictInsertStatement(t *testing.T) {
+	order := []string{"email", "name", "age"}
+
+	tests := []struct {
+		conflict Conflict
+		expected string
+	}{
+		{
+			Conflict{On: []string{"email"}, Do: "ignore"},
+			"INSERT INTO user (email, name, age) VALUES (?, ?, ?) ON CONFLICT (email) DO NOTHING",
+		},
+		{
+			Conflict{On: []string{"email"}, Do: "update"},
+			"INSERT INTO user (email, name, age) VALUES (?, ?, ?) ON CONFLICT (email) DO UPDATE SET name = excluded.name, age = excluded.age",
+		},
+	}
+
+	for _, tt := range tests {
+		got := tt.conflict.insertStatement("user", order)
+
+		if got != tt.expected {
+			t.Errorf("Expected %v, but got %v", tt.expected, got)
+		}
+	}
+}
+
+func TestConflictValidate(t *testing.T) {
+	fields := map[string]Insertable{
+		"email": &RegularInsertion{value: "Email"},
+		"name":  &RegularInsertion{value: "Name"},
+	}
+
+	tests := []struct {
+		conflict   Conflict
+		shouldFail bool
+	}{
+		{Conflict{Do: "error"}, false},
+		{Conflict{On: []string{"email"}, Do: "ignore"}, false},
+		{Conflict{On: []string{"email"}, Do: "update", Update: []string{"name"}}, false},
+		{Conflict{On: []string{"email"}, Do: "replace"}, true},
+		{Conflict{On: []string{"email"}}, true},
+		{Conflict{Do: "ignore"}, true},
+		{Conflict{On: []string{"id"}, Do: "ignore"}, true},
+		{Conflict{On: []string{"email"}, Do: "update", Update: []string{"age"}}, true},
+	}
+
+	for _, tt := range tests {
+		err := tt.conflict.validate(fields)
+
+		if (err != nil) != tt.shouldFail {
+			t.Errorf("Expected %v to fail: %v, but got %v", tt.conflict, tt.shouldFail, err)
+		}
+	}
+}
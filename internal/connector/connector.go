@@ -4,6 +4,9 @@ import "database/sql"
 
 type Connector interface {
 	Connect(url string) *sql.DB
+	// Name of the sql dialect the database speaks, used for statements each
+	// database writes in its own way
+	Dialect() string
 }
 
 func PickConnector(databaseName string) Connector {
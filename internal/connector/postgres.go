@@ -25,3 +25,7 @@ func (pg *Postgres) Connect(url string) *sql.DB {
 
 	return db
 }
+
+func (pg *Postgres) Dialect() string {
+	return "postgres"
+}
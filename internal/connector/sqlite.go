@@ -25,3 +25,7 @@ func (pg *Sqlite) Connect(url string) *sql.DB {
 
 	return db
 }
+
+func (pg *Sqlite) Dialect() string {
+	return "sqlite"
+}
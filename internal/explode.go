@@ -0,0 +1,136 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/marcos-brito/sozza/internal/source"
+)
+
+// Repeats the insertions of a table once for each element of a list, like
+// a Tags column with red;blue;green
+type Explode struct {
+	// Field value holding the list
+	Value string `yaml:"value"`
+	// What the elements are separated by. Ignored for json arrays
+	Separator string `yaml:"separator"`
+	// Whether the value is a json array instead of separated text
+	Json bool `yaml:"json"`
+}
+
+const (
+	elementColumn = "$element"
+	indexColumn   = "$index"
+)
+
+type explosion struct {
+	value     Insertable
+	separator string
+	json      bool
+}
+
+// The record of a single element. $element and $index are the element and
+// its position, starting at 0. Other columns come from the whole record
+type explodedRecord struct {
+	source.Record
+	element any
+	index   int64
+}
+
+func (p *Parser) parseExplode(explode *Explode) (*explosion, error) {
+	if explode.Separator == "" && !explode.Json {
+		return nil, fmt.Errorf("Either a separator or json must be given")
+	}
+
+	value, err := p.parseFieldValue(explode.Value)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &explosion{value: value, separator: explode.Separator, json: explode.Json}, nil
+}
+
+func (r *explodedRecord) Get(field string) (any, error) {
+	switch field {
+	case elementColumn:
+		return r.element, nil
+	case indexColumn:
+		return r.index, nil
+	default:
+		return r.Record.Get(field)
+	}
+}
+
+// Elements are trimmed and the empty ones are left out, so an empty cell
+// inserts nothing
+func (e *explosion) elements(context InsertContext) ([]any, error) {
+	value, err := e.value.generateValue(context)
+
+	if err != nil {
+		return nil, err
+	}
+
+	text := strings.TrimSpace(toString(value))
+	if value == nil || text == "" {
+		return []any{}, nil
+	}
+
+	if e.json {
+		elements := []any{}
+		decoder := json.NewDecoder(strings.NewReader(text))
+		decoder.UseNumber()
+
+		if err := decoder.Decode(&elements); err != nil {
+			return nil, fmt.Errorf("%s is not a json array: %s", text, err)
+		}
+
+		// Elements are text, like the values of json inputs. Objects and
+		// arrays are inserted as json
+		for idx, element := range elements {
+			switch element.(type) {
+			case nil:
+			case map[string]any, []any:
+				encoded, _ := json.Marshal(element)
+				elements[idx] = string(encoded)
+			default:
+				elements[idx] = toString(element)
+			}
+		}
+
+		return elements, nil
+	}
+
+	elements := []any{}
+	for _, element := range strings.Split(text, e.separator) {
+		if element = strings.TrimSpace(element); element != "" {
+			elements = append(elements, element)
+		}
+	}
+
+	return elements, nil
+}
+
+// Gives a context for each row the table inserts for the record. Only one,
+// unless the table is exploded
+func (t *Table) explodeContext(context InsertContext) ([]InsertContext, error) {
+	if t.explode == nil {
+		return []InsertContext{context}, nil
+	}
+
+	elements, err := t.explode.elements(context)
+
+	if err != nil {
+		return nil, fmt.Errorf("Error exploding %s: %s", t.name, err)
+	}
+
+	contexts := []InsertContext{}
+	for idx, element := range elements {
+		exploded := context
+		exploded.record = &explodedRecord{Record: context.record, element: element, index: int64(idx)}
+		contexts = append(contexts, exploded)
+	}
+
+	return contexts, nil
+}
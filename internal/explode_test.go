@@ -0,0 +1,94 @@
+package internal
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExplodeContext(t *testing.T) {
+	tests := []struct {
+		explode    Explode
+		tags       any
+		expected   [][]any
+		shouldFail bool
+	}{
+		{
+			Explode{Value: "Tags", Separator: ";"},
+			"red; blue;;green",
+			[][]any{{"red", int64(0)}, {"blue", int64(1)}, {"green", int64(2)}},
+			false,
+		},
+		{
+			Explode{Value: "Tags", Separator: ";"},
+			"",
+			[][]any{},
+			false,
+		},
+		{
+			Explode{Value: "Tags", Separator: ";"},
+			nil,
+			[][]any{},
+			false,
+		},
+		{
+			Explode{Value: "upper(Tags)", Separator: ","},
+			"a,b",
+			[][]any{{"A", int64(0)}, {"B", int64(1)}},
+			false,
+		},
+		{
+			Explode{Value: "Tags", Json: true},
+			`["red", 2, null, {"name": "blue"}]`,
+			[][]any{{"red", int64(0)}, {"2", int64(1)}, {nil, int64(2)}, {`{"name":"blue"}`, int64(3)}},
+			false,
+		},
+		{
+			Explode{Value: "Tags", Json: true},
+			"red;blue",
+			nil,
+			true,
+		},
+	}
+
+	for _, tt := range tests {
+		explode, err := NewParser(nil).parseExplode(&tt.explode)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		table := newTable("user_tags", "", map[string]Insertable{})
+		table.explode = explode
+
+		contexts, err := table.explodeContext(InsertContext{record: mapRecord{"Tags": tt.tags}})
+
+		if err != nil {
+			if !tt.shouldFail {
+				t.Errorf("Expected %v to be exploded, but got %s", tt.tags, err)
+			}
+
+			continue
+		}
+
+		if tt.shouldFail {
+			t.Errorf("Expected %v to fail", tt.tags)
+		}
+
+		got := [][]any{}
+		for _, context := range contexts {
+			element, _ := context.record.Get(elementColumn)
+			index, _ := context.record.Get(indexColumn)
+			got = append(got, []any{element, index})
+		}
+
+		if !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("Expected %v, but got %v", tt.expected, got)
+		}
+	}
+}
+
+func TestParseExplode(t *testing.T) {
+	if _, err := NewParser(nil).parseExplode(&Explode{Value: "Tags"}); err == nil {
+		t.Errorf("Expected an explode without separator or json to fail")
+	}
+}
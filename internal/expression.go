@@ -0,0 +1,143 @@
+package internal
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Parses the tokens of function calls and their arguments
+type expressionParser struct {
+	parser   *Parser
+	tokens   []token
+	position int
+}
+
+func (e *expressionParser) done() bool {
+	return e.position >= len(e.tokens)
+}
+
+func (e *expressionParser) peek() token {
+	if e.done() {
+		return token{kind: symbolToken, text: "end of value"}
+	}
+
+	return e.tokens[e.position]
+}
+
+func (e *expressionParser) next() token {
+	token := e.peek()
+	e.position++
+
+	return token
+}
+
+func (e *expressionParser) expect(symbol string) error {
+	if token := e.next(); token.kind != symbolToken || token.text != symbol {
+		return fmt.Errorf("Expected %s, but found %s", symbol, token.text)
+	}
+
+	return nil
+}
+
+// Operands are literals, columns, table references and function calls
+func (e *expressionParser) parseOperand() (Insertable, error) {
+	current := e.peek()
+
+	switch current.kind {
+	case stringToken:
+		e.next()
+		return &Literal{value: current.text}, nil
+	case numberToken:
+		e.next()
+		return e.parser.parseLiteral(current.text)
+	case columnToken:
+		e.next()
+		return e.parser.parseRegularInsertion(current.text)
+	case identToken:
+		if e.position+1 < len(e.tokens) && e.tokens[e.position+1].text == "(" {
+			return e.parseCall(nil)
+		}
+
+		e.next()
+		return e.parseIdent(current.text)
+	default:
+		return nil, fmt.Errorf("Unexpected %s", current.text)
+	}
+}
+
+func (e *expressionParser) parseIdent(text string) (Insertable, error) {
+	switch {
+	case isBoolean(text) || text == "null":
+		return e.parser.parseLiteral(text)
+	case strings.HasPrefix(text, "__"):
+		return e.parser.parseTableReference(text)
+	default:
+		return e.parser.parseRegularInsertion(text)
+	}
+}
+
+// Parses name or name(args). The piped value, if any, becomes the first
+// argument
+func (e *expressionParser) parseCall(piped Insertable) (Insertable, error) {
+	name := e.next()
+
+	if name.kind != identToken {
+		return nil, fmt.Errorf("Expected a function, but found %s", name.text)
+	}
+
+	if name.text == lookupName {
+		if piped != nil {
+			return nil, fmt.Errorf("%s can't be piped", lookupName)
+		}
+
+		return e.parseLookup()
+	}
+
+	if name.text == fakeName {
+		if piped != nil {
+			return nil, fmt.Errorf("%s can't be piped", fakeName)
+		}
+
+		return e.parseFake()
+	}
+
+	if isGenerator(name.text) {
+		if piped != nil {
+			return nil, fmt.Errorf("%s can't be piped", name.text)
+		}
+
+		return e.parseGenerator(name.text)
+	}
+
+	args := []Insertable{}
+	if piped != nil {
+		args = append(args, piped)
+	}
+
+	if e.done() || e.peek().text != "(" {
+		return newFunction(name.text, args)
+	}
+
+	e.next()
+	for first := true; e.peek().text != ")"; first = false {
+		if !first {
+			if err := e.expect(","); err != nil {
+				return nil, err
+			}
+		}
+
+		arg, err := e.parseOperand()
+
+		if err != nil {
+			return nil, err
+		}
+
+		args = append(args, arg)
+	}
+
+	if err := e.expect(")"); err != nil {
+		return nil, err
+	}
+
+	return newFunction(name.text, args)
+}
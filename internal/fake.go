@@ -0,0 +1,302 @@
+package internal
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math"
+	"math/rand"
+	"strings"
+	"time"
+)
+
+// Synthetic data, like fake('email') or fake('int', 1, 100). Each call has
+// its own random source, seeded from the seed of the mapping and where the
+// call is, so the same mapping always gives the same values
+type Fake struct {
+	kind   string
+	args   []Insertable
+	random *rand.Rand
+}
+
+type faker struct {
+	minArgs  int
+	maxArgs  int
+	generate func(random *rand.Rand, args []any) (any, error)
+}
+
+const fakeName = "fake"
+
+var fakers = map[string]faker{
+	"first_name": {0, 0, pick(firstNames)},
+	"last_name":  {0, 0, pick(lastNames)},
+	"name":       {0, 0, fakeFullName},
+	"email":      {0, 0, fakeEmail},
+	"phone":      {0, 0, fakePhone},
+	"street":     {0, 0, fakeStreet},
+	"city":       {0, 0, pick(cities)},
+	"country":    {0, 0, pick(countries)},
+	"zip":        {0, 0, fakeZip},
+	"address":    {0, 0, fakeAddress},
+	"company":    {0, 0, fakeCompany},
+	"word":       {0, 0, pick(loremWords)},
+	"lorem":      {0, 1, fakeLorem},
+	"int":        {2, 2, fakeInt},
+	"float":      {2, 2, fakeFloat},
+	"bool":       {0, 0, fakeBool},
+	"date":       {2, 2, fakeDate},
+}
+
+var (
+	firstNames = []string{
+		"Ana", "Bruno", "Carla", "Daniel", "Elena", "Felipe", "Gabriela", "Hugo", "Isabel", "Joana",
+		"Karen", "Lucas", "Marina", "Nicolas", "Olivia", "Pedro", "Quiteria", "Rafael", "Sofia", "Tiago",
+		"Ursula", "Vitor", "Wanda", "Xavier", "Yara", "Zeca", "Alice", "Bernardo", "Clara", "Diego",
+	}
+	lastNames = []string{
+		"Silva", "Santos", "Oliveira", "Souza", "Rodrigues", "Ferreira", "Alves", "Pereira", "Lima", "Gomes",
+		"Costa", "Ribeiro", "Martins", "Carvalho", "Almeida", "Lopes", "Soares", "Fernandes", "Vieira", "Barbosa",
+		"Smith", "Johnson", "Brown", "Garcia", "Miller", "Davis", "Wilson", "Moore", "Taylor", "Clark",
+	}
+	streets = []string{
+		"Main", "Oak", "Pine", "Maple", "Cedar", "Elm", "Lake", "Hill", "Park", "River",
+		"Sunset", "Church", "Market", "Spring", "Forest", "Garden", "Bridge", "Mill", "Station", "Harbor",
+	}
+	streetSuffixes = []string{"Street", "Avenue", "Road", "Lane", "Boulevard", "Drive", "Way", "Court"}
+	cities         = []string{
+		"Recife", "Lisbon", "Porto", "Salvador", "Curitiba", "Austin", "Denver", "Madrid", "Lyon", "Toronto",
+		"Dublin", "Oslo", "Kyoto", "Cusco", "Quito", "Natal", "Belém", "Florence", "Seville", "Krakow",
+	}
+	countries = []string{
+		"Brazil", "Portugal", "Spain", "France", "Canada", "United States", "Ireland", "Norway", "Japan", "Peru",
+		"Ecuador", "Italy", "Poland", "Argentina", "Chile", "Mexico", "Germany", "Netherlands", "Sweden", "Uruguay",
+	}
+	companyWords    = []string{"Acme", "Globex", "Initech", "Umbrella", "Stark", "Wayne", "Tyrell", "Cyberdyne", "Soylent", "Hooli"}
+	companySuffixes = []string{"Inc", "Ltd", "LLC", "Group", "Systems", "Labs", "Partners", "Industries"}
+	emailDomains    = []string{"example.com", "example.org", "example.net"}
+	loremWords      = []string{
+		"lorem", "ipsum", "dolor", "sit", "amet", "consectetur", "adipiscing", "elit", "sed", "do",
+		"eiusmod", "tempor", "incididunt", "ut", "labore", "et", "dolore", "magna", "aliqua", "enim",
+		"ad", "minim", "veniam", "quis", "nostrud", "exercitation", "ullamco", "laboris", "nisi", "aliquip",
+	}
+)
+
+// Parses fake after its name. The kind of data comes first, quoted, and
+// the other arguments are written like function arguments
+func (e *expressionParser) parseFake() (Insertable, error) {
+	if err := e.expect("("); err != nil {
+		return nil, err
+	}
+
+	kind := e.next()
+	if kind.kind != stringToken {
+		return nil, fmt.Errorf("Expected the kind of data, like 'email', but found %s", kind.text)
+	}
+
+	faker, ok := fakers[kind.text]
+	if !ok {
+		return nil, fmt.Errorf("Unknown kind of fake data %s", kind.text)
+	}
+
+	args := []Insertable{}
+	for e.peek().text != ")" {
+		if err := e.expect(","); err != nil {
+			return nil, err
+		}
+
+		arg, err := e.parseOperand()
+
+		if err != nil {
+			return nil, err
+		}
+
+		args = append(args, arg)
+	}
+
+	if err := e.expect(")"); err != nil {
+		return nil, err
+	}
+
+	if len(args) < faker.minArgs || len(args) > faker.maxArgs {
+		return nil, fmt.Errorf("fake('%s') can't take %d arguments", kind.text, len(args))
+	}
+
+	return &Fake{kind: kind.text, args: args, random: e.parser.newRandom()}, nil
+}
+
+// Every call gets a different seed from the place it is in. The location is
+// set before each field, condition, explode and filter is parsed
+func (p *Parser) newRandom() *rand.Rand {
+	p.fakes++
+
+	hash := fnv.New64a()
+	fmt.Fprintf(hash, "%d:%s:%d", p.seed(), p.location, p.fakes)
+
+	return rand.New(rand.NewSource(int64(hash.Sum64())))
+}
+
+func (p *Parser) seed() int64 {
+	if p.mapping == nil {
+		return 0
+	}
+
+	return p.mapping.Seed
+}
+
+func (f *Fake) generateValue(context InsertContext) (any, error) {
+	args := []any{}
+
+	for _, arg := range f.args {
+		value, err := arg.generateValue(context)
+
+		if err != nil {
+			return nil, err
+		}
+
+		args = append(args, value)
+	}
+
+	value, err := fakers[f.kind].generate(f.random, args)
+
+	if err != nil {
+		return nil, fmt.Errorf("Error faking %s: %s", f.kind, err)
+	}
+
+	return value, nil
+}
+
+func pick(options []string) func(random *rand.Rand, args []any) (any, error) {
+	return func(random *rand.Rand, args []any) (any, error) {
+		return options[random.Intn(len(options))], nil
+	}
+}
+
+func choose(random *rand.Rand, options []string) string {
+	return options[random.Intn(len(options))]
+}
+
+func fakeFullName(random *rand.Rand, args []any) (any, error) {
+	return choose(random, firstNames) + " " + choose(random, lastNames), nil
+}
+
+func fakeEmail(random *rand.Rand, args []any) (any, error) {
+	user := fmt.Sprintf("%s.%s%d", choose(random, firstNames), choose(random, lastNames), random.Intn(1000))
+
+	return strings.ToLower(user) + "@" + choose(random, emailDomains), nil
+}
+
+func fakePhone(random *rand.Rand, args []any) (any, error) {
+	return fmt.Sprintf("(%03d) %03d-%04d", 200+random.Intn(800), random.Intn(1000), random.Intn(10000)), nil
+}
+
+func fakeStreet(random *rand.Rand, args []any) (any, error) {
+	return fmt.Sprintf("%d %s %s", 1+random.Intn(9999), choose(random, streets), choose(random, streetSuffixes)), nil
+}
+
+func fakeZip(random *rand.Rand, args []any) (any, error) {
+	return fmt.Sprintf("%05d", random.Intn(100000)), nil
+}
+
+func fakeAddress(random *rand.Rand, args []any) (any, error) {
+	street, _ := fakeStreet(random, args)
+	zip, _ := fakeZip(random, args)
+
+	return fmt.Sprintf("%s, %s %s", street, choose(random, cities), zip), nil
+}
+
+func fakeCompany(random *rand.Rand, args []any) (any, error) {
+	return choose(random, companyWords) + " " + choose(random, companySuffixes), nil
+}
+
+// A sentence with the given number of words, 8 by default
+func fakeLorem(random *rand.Rand, args []any) (any, error) {
+	length := 8
+
+	if len(args) == 1 {
+		var err error
+		if length, err = toInteger(args[0]); err != nil {
+			return nil, err
+		}
+	}
+
+	if length < 1 {
+		return nil, fmt.Errorf("the number of words must be positive")
+	}
+
+	words := []string{}
+	for range length {
+		words = append(words, choose(random, loremWords))
+	}
+
+	sentence := strings.Join(words, " ")
+
+	return strings.ToUpper(sentence[:1]) + sentence[1:] + ".", nil
+}
+
+// Between min and max, both included
+func fakeInt(random *rand.Rand, args []any) (any, error) {
+	low, err := toInteger(args[0])
+
+	if err != nil {
+		return nil, err
+	}
+
+	high, err := toInteger(args[1])
+
+	if err != nil {
+		return nil, err
+	}
+
+	if high < low {
+		return nil, fmt.Errorf("%d is less than %d", high, low)
+	}
+
+	// The number of values must fit in an int64
+	if (low < 0 && int64(high) > math.MaxInt64+int64(low)) || int64(high)-int64(low) == math.MaxInt64 {
+		return nil, fmt.Errorf("the range from %d to %d is too large", low, high)
+	}
+
+	return int64(low) + random.Int63n(int64(high)-int64(low)+1), nil
+}
+
+func fakeFloat(random *rand.Rand, args []any) (any, error) {
+	low, err := toFloat(args[0])
+
+	if err != nil {
+		return nil, fmt.Errorf("%v is not a number", args[0])
+	}
+
+	high, err := toFloat(args[1])
+
+	if err != nil {
+		return nil, fmt.Errorf("%v is not a number", args[1])
+	}
+
+	return low.(float64) + random.Float64()*(high.(float64)-low.(float64)), nil
+}
+
+func fakeBool(random *rand.Rand, args []any) (any, error) {
+	return random.Intn(2) == 1, nil
+}
+
+// A day between the two dates, written as 2006-01-02
+func fakeDate(random *rand.Rand, args []any) (any, error) {
+	start, err := time.Parse(time.DateOnly, toString(args[0]))
+
+	if err != nil {
+		return nil, fmt.Errorf("%v is not a date", args[0])
+	}
+
+	end, err := time.Parse(time.DateOnly, toString(args[1]))
+
+	if err != nil {
+		return nil, fmt.Errorf("%v is not a date", args[1])
+	}
+
+	days := int(end.Sub(start).Hours() / 24)
+
+	if days < 0 {
+		return nil, fmt.Errorf("%v is before %v", args[1], args[0])
+	}
+
+	return start.AddDate(0, 0, random.Intn(days+1)).Format(time.DateOnly), nil
+}
@@ -0,0 +1,145 @@
+package internal
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+func TestFake(t *testing.T) {
+	tests := []struct {
+		input      string
+		pattern    string
+		shouldFail bool
+	}{
+		{"fake('first_name')", `^\pL+$`, false},
+		{"fake('name')", `^\pL+ \pL+$`, false},
+		{"fake('email')", `^[\pL.]+\d+@example\.(com|org|net)$`, false},
+		{"fake('phone')", `^\(\d{3}\) \d{3}-\d{4}$`, false},
+		{"fake('address')", `^\d+ \w+ \w+, [\pL ]+ \d{5}$`, false},
+		{"fake('lorem', 3)", `^[A-Z][a-z]* [a-z]+ [a-z]+\.$`, false},
+		{"fake('int', 5, 7)", `^[567]$`, false},
+		{"fake('int', Min, 7)", `^[1-7]$`, false},
+		{"fake('date', '2024-02-27', '2024-03-01')", `^2024-(02-2[789]|03-01)$`, false},
+		{"fake('bool')", `^(true|false)$`, false},
+		{"fake('int', 0, 9223372036854775806)", `^\d+$`, false},
+		{"fake('int', 7, 5)", ``, true},
+		{"fake('int', -1, 9223372036854775807)", ``, true},
+		{"fake('int', -9223372036854775808, 9223372036854775807)", ``, true},
+		{"fake('int', 5)", ``, true},
+		{"fake('color')", ``, true},
+		{"fake(email)", ``, true},
+	}
+
+	for _, tt := range tests {
+		fake, err := NewParser(nil).parseFieldValue(tt.input)
+
+		var value any
+		for range 20 {
+			if err != nil {
+				break
+			}
+
+			value, err = fake.generateValue(InsertContext{record: mapRecord{"Min": "1"}})
+
+			if err == nil && !regexp.MustCompile(tt.pattern).MatchString(toString(value)) {
+				t.Errorf("Expected %v to match %s for %s", value, tt.pattern, tt.input)
+			}
+		}
+
+		if err != nil && !tt.shouldFail {
+			t.Errorf("Expected %s to be faked, but got %s", tt.input, err)
+		}
+
+		if err == nil && tt.shouldFail {
+			t.Errorf("Expected %s to fail, but got %v", tt.input, value)
+		}
+	}
+}
+
+func TestFakeIsDeterministic(t *testing.T) {
+	generate := func(seed int64, location string) []any {
+		parser := NewParser(&Mapping{Seed: seed})
+		parser.location = location
+		fake, _ := parser.parseFieldValue("fake('email')")
+
+		values := []any{}
+		for range 5 {
+			value, _ := fake.generateValue(InsertContext{})
+			values = append(values, value)
+		}
+
+		return values
+	}
+
+	first, second := generate(42, "user:0:email"), generate(42, "user:0:email")
+	for idx := range first {
+		if first[idx] != second[idx] {
+			t.Errorf("Expected %v, but got %v", first[idx], second[idx])
+		}
+	}
+
+	other, elsewhere := generate(7, "user:0:email"), generate(42, "admin:0:email")
+	if first[0] == other[0] && first[1] == other[1] || first[0] == elsewhere[0] && first[1] == elsewhere[1] {
+		t.Errorf("Expected other seeds and locations to give other values, but got %v", first)
+	}
+}
+
+func TestFakeLocations(t *testing.T) {
+	mapping, err := ReadMapping([]byte(`
+seed: 42
+house:
+  explode:
+    value: fake('lorem', 3)
+    separator: " "
+  insertions:
+    - color: fake('word')
+      when: fake('int', 1, 1000000) > 0
+user:
+  insertions:
+    - name: fake('name')
+      age: fake('int', 1, 1000000)
+`), nil)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Tables are parsed in any order, which must not change the values
+	generate := func() []any {
+		parser := NewParser(mapping)
+		tables, err := parser.parse()
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		filter, err := parser.parseFilter("", "fake('int', 1, 1000000) > 0")
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		values := []any{}
+		for _, table := range tables {
+			if table.explode == nil {
+				continue
+			}
+
+			elements, _ := table.explode.value.generateValue(InsertContext{})
+			condition, _ := table.condition.(*Comparison).left.generateValue(InsertContext{})
+			values = append(values, elements, condition)
+		}
+
+		value, _ := filter.(*Comparison).left.generateValue(InsertContext{})
+
+		return append(values, value)
+	}
+
+	first := generate()
+	for range 20 {
+		if got := generate(); !reflect.DeepEqual(first, got) {
+			t.Fatalf("Expected %v, but got %v", first, got)
+		}
+	}
+}
@@ -0,0 +1,334 @@
+package internal
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// A call to one of the builtin functions, like lower(Email). When used in
+// a pipeline such as Email | trim | lower, the value on the left is passed
+// as the first argument
+type Function struct {
+	name string
+	args []Insertable
+	// Pattern of regex_extract, compiled when parsing if it is a literal
+	pattern *regexp.Regexp
+}
+
+type builtin struct {
+	minArgs int
+	// -1 for any number of arguments
+	maxArgs int
+	call    func(args []any) (any, error)
+}
+
+var builtins = map[string]builtin{
+	"trim":          {1, 2, trimFunction},
+	"lower":         {1, 1, stringFunction(strings.ToLower)},
+	"upper":         {1, 1, stringFunction(strings.ToUpper)},
+	"replace":       {3, 3, replaceFunction},
+	"substr":        {2, 3, substrFunction},
+	"split":         {3, 3, splitFunction},
+	"coalesce":      {1, -1, coalesceFunction},
+	"concat":        {1, -1, concatFunction},
+	"regex_extract": {2, 3, regexExtractFunction},
+	"pad":           {2, 4, padFunction},
+	"hash":          {1, 2, hashFunction},
+}
+
+func isBuiltin(name string) bool {
+	_, ok := builtins[name]
+
+	return ok
+}
+
+func newFunction(name string, args []Insertable) (*Function, error) {
+	function, ok := builtins[name]
+
+	if !ok {
+		return nil, fmt.Errorf("Unknown function %s", name)
+	}
+
+	if len(args) < function.minArgs || (function.maxArgs >= 0 && len(args) > function.maxArgs) {
+		return nil, fmt.Errorf("%s can't take %d arguments", name, len(args))
+	}
+
+	call := &Function{name: name, args: args}
+
+	// Literal patterns are compiled once instead of for every record
+	if literal, ok := call.literalPattern(); ok {
+		pattern, err := regexp.Compile(toString(literal.value))
+
+		if err != nil {
+			return nil, fmt.Errorf("Invalid pattern for %s: %s", name, err)
+		}
+
+		call.pattern = pattern
+	}
+
+	return call, nil
+}
+
+func (f *Function) literalPattern() (*Literal, bool) {
+	if f.name != "regex_extract" {
+		return nil, false
+	}
+
+	literal, ok := f.args[1].(*Literal)
+
+	return literal, ok
+}
+
+func (f *Function) generateValue(context InsertContext) (any, error) {
+	args := []any{}
+
+	for _, arg := range f.args {
+		value, err := arg.generateValue(context)
+
+		if err != nil {
+			return nil, err
+		}
+
+		args = append(args, value)
+	}
+
+	if f.pattern != nil {
+		args[1] = f.pattern
+	}
+
+	value, err := builtins[f.name].call(args)
+
+	if err != nil {
+		return nil, fmt.Errorf("Error calling %s: %s", f.name, err)
+	}
+
+	return value, nil
+}
+
+// Most functions return NULL when their first argument is NULL
+func stringFunction(transform func(string) string) func(args []any) (any, error) {
+	return func(args []any) (any, error) {
+		if args[0] == nil {
+			return nil, nil
+		}
+
+		return transform(toString(args[0])), nil
+	}
+}
+
+func toInteger(value any) (int, error) {
+	converted, err := toInt(value)
+
+	if err != nil {
+		return 0, fmt.Errorf("%v is not an integer", value)
+	}
+
+	return int(converted.(int64)), nil
+}
+
+func trimFunction(args []any) (any, error) {
+	if args[0] == nil {
+		return nil, nil
+	}
+
+	if len(args) == 1 {
+		return strings.TrimSpace(toString(args[0])), nil
+	}
+
+	return strings.Trim(toString(args[0]), toString(args[1])), nil
+}
+
+func replaceFunction(args []any) (any, error) {
+	if args[0] == nil {
+		return nil, nil
+	}
+
+	return strings.ReplaceAll(toString(args[0]), toString(args[1]), toString(args[2])), nil
+}
+
+// The start is counted in characters from 0
+func substrFunction(args []any) (any, error) {
+	if args[0] == nil {
+		return nil, nil
+	}
+
+	characters := []rune(toString(args[0]))
+	start, err := toInteger(args[1])
+
+	if err != nil {
+		return nil, err
+	}
+
+	length := len(characters)
+	if len(args) == 3 {
+		if length, err = toInteger(args[2]); err != nil {
+			return nil, err
+		}
+	}
+
+	if start < 0 || length < 0 {
+		return nil, fmt.Errorf("start and length can't be negative")
+	}
+
+	start = min(start, len(characters))
+	end := min(start+length, len(characters))
+
+	return string(characters[start:end]), nil
+}
+
+// Returns the element at index, starting at 0, or an empty string if there
+// are not enough elements
+func splitFunction(args []any) (any, error) {
+	if args[0] == nil {
+		return nil, nil
+	}
+
+	index, err := toInteger(args[2])
+
+	if err != nil {
+		return nil, err
+	}
+
+	elements := strings.Split(toString(args[0]), toString(args[1]))
+
+	if index < 0 || index >= len(elements) {
+		return "", nil
+	}
+
+	return elements[index], nil
+}
+
+// Empty strings are skipped the same way as NULL, since that's what empty
+// csv cells are
+func coalesceFunction(args []any) (any, error) {
+	for _, arg := range args {
+		if arg != nil && arg != "" {
+			return arg, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func concatFunction(args []any) (any, error) {
+	text := strings.Builder{}
+
+	for _, arg := range args {
+		text.WriteString(toString(arg))
+	}
+
+	return text.String(), nil
+}
+
+// Returns the whole match or one of its groups. An empty string is
+// returned when nothing matches. The pattern is already compiled when it is
+// a literal, and compiled on each call otherwise
+func regexExtractFunction(args []any) (any, error) {
+	if args[0] == nil {
+		return nil, nil
+	}
+
+	expression, ok := args[1].(*regexp.Regexp)
+
+	if !ok {
+		var err error
+		if expression, err = regexp.Compile(toString(args[1])); err != nil {
+			return nil, err
+		}
+	}
+
+	group := 0
+	if len(args) == 3 {
+		var err error
+		if group, err = toInteger(args[2]); err != nil {
+			return nil, err
+		}
+	}
+
+	if group < 0 || group > expression.NumSubexp() {
+		return nil, fmt.Errorf("%s has no group %d", expression, group)
+	}
+
+	match := expression.FindStringSubmatch(toString(args[0]))
+
+	if match == nil {
+		return "", nil
+	}
+
+	return match[group], nil
+}
+
+// Pads the value up to length characters with spaces or the given
+// character. It pads to the left unless the side is 'right'
+func padFunction(args []any) (any, error) {
+	if args[0] == nil {
+		return nil, nil
+	}
+
+	text := toString(args[0])
+	length, err := toInteger(args[1])
+
+	if err != nil {
+		return nil, err
+	}
+
+	padding := " "
+	if len(args) >= 3 {
+		padding = toString(args[2])
+	}
+
+	if len([]rune(padding)) != 1 {
+		return nil, fmt.Errorf("the padding must be a single character, but got %s", strconv.Quote(padding))
+	}
+
+	side := "left"
+	if len(args) == 4 {
+		side = toString(args[3])
+	}
+
+	missing := strings.Repeat(padding, max(length-len([]rune(text)), 0))
+
+	switch side {
+	case "left":
+		return missing + text, nil
+	case "right":
+		return text + missing, nil
+	default:
+		return nil, fmt.Errorf("the side must be left or right, but got %s", side)
+	}
+}
+
+// Hex encoded md5, sha1 or sha256, which is the default
+func hashFunction(args []any) (any, error) {
+	if args[0] == nil {
+		return nil, nil
+	}
+
+	algorithm := "sha256"
+	if len(args) == 2 {
+		algorithm = toString(args[1])
+	}
+
+	var hasher hash.Hash
+	switch algorithm {
+	case "md5":
+		hasher = md5.New()
+	case "sha1":
+		hasher = sha1.New()
+	case "sha256":
+		hasher = sha256.New()
+	default:
+		return nil, fmt.Errorf("unknown algorithm %s", algorithm)
+	}
+
+	hasher.Write([]byte(toString(args[0])))
+
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
@@ -0,0 +1,100 @@
+package internal
+
+import (
+	"testing"
+)
+
+func TestFunctions(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []any
+		expected   any
+		shouldFail bool
+	}{
+		{"trim", []any{"  john  "}, "john", false},
+		{"trim", []any{"--john--", "-"}, "john", false},
+		{"trim", []any{nil}, nil, false},
+		{"lower", []any{"JOHN@Mail.com"}, "john@mail.com", false},
+		{"upper", []any{"john"}, "JOHN", false},
+		{"replace", []any{"a b c", " ", "_"}, "a_b_c", false},
+		{"substr", []any{"sozza", int64(1), int64(3)}, "ozz", false},
+		{"substr", []any{"sozza", int64(2)}, "zza", false},
+		{"substr", []any{"são", int64(1), int64(10)}, "ão", false},
+		{"substr", []any{"sozza", int64(-1)}, nil, true},
+		{"split", []any{"red;blue;green", ";", int64(1)}, "blue", false},
+		{"split", []any{"red;blue", ";", int64(5)}, "", false},
+		{"coalesce", []any{nil, "", "fallback", "other"}, "fallback", false},
+		{"coalesce", []any{nil, ""}, nil, false},
+		{"concat", []any{"John", " ", nil, "Doe"}, "John Doe", false},
+		{"regex_extract", []any{"Phone: 555-1234", `(\d+)-(\d+)`}, "555-1234", false},
+		{"regex_extract", []any{"Phone: 555-1234", `(\d+)-(\d+)`, int64(2)}, "1234", false},
+		{"regex_extract", []any{"no digits", `\d+`}, "", false},
+		{"regex_extract", []any{"abc", `(a)`, int64(3)}, nil, true},
+		{"regex_extract", []any{"abc", `(a`}, nil, true},
+		{"pad", []any{"42", int64(5), "0"}, "00042", false},
+		{"pad", []any{"42", int64(4), ".", "right"}, "42..", false},
+		{"pad", []any{"123456", int64(3)}, "123456", false},
+		{"pad", []any{"42", int64(5), "ab"}, nil, true},
+		{"hash", []any{"sozza", "md5"}, "e9e47860089edad655a5f3d659386dc3", false},
+		{"hash", []any{"abc"}, "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad", false},
+		{"hash", []any{"abc", "crc32"}, nil, true},
+	}
+
+	for _, tt := range tests {
+		args := []Insertable{}
+		for _, arg := range tt.args {
+			args = append(args, &Literal{value: arg})
+		}
+
+		// Literal patterns fail when the function is created
+		function, err := newFunction(tt.name, args)
+
+		if err != nil {
+			if !tt.shouldFail {
+				t.Error(err)
+			}
+			continue
+		}
+
+		got, err := function.generateValue(InsertContext{})
+
+		if tt.shouldFail {
+			if err == nil {
+				t.Errorf("Expected %s%v to fail, but got %v", tt.name, tt.args, got)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Error(err)
+		}
+
+		if got != tt.expected {
+			t.Errorf("Expected %v, but got %v: %s%v", tt.expected, got, tt.name, tt.args)
+		}
+	}
+}
+
+func TestNewFunction(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       int
+		shouldFail bool
+	}{
+		{"lower", 1, false},
+		{"lower", 2, true},
+		{"replace", 2, true},
+		{"concat", 5, false},
+		{"coalesce", 0, true},
+		{"frobnicate", 1, true},
+	}
+
+	for _, tt := range tests {
+		args := make([]Insertable, tt.args)
+		_, err := newFunction(tt.name, args)
+
+		if tt.shouldFail != (err != nil) {
+			t.Errorf("Expected %s with %d arguments to fail: %t, but got %v", tt.name, tt.args, tt.shouldFail, err)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package internal
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Generates keys without asking the database, like uuid(), ulid() or
+// seq(start, step). When a field is generated, it is also what references
+// to its table get instead of the id the database gives
+type Generator struct {
+	name string
+	// Next value of seq and how much it grows
+	next int64
+	step int64
+	// Where seq started, to tell whether the insertions of a table
+	// declare the same sequence for a column
+	start int64
+}
+
+var generators = map[string]int{
+	"uuid": 0,
+	"ulid": 0,
+	"seq":  2,
+}
+
+// Crockford's base32, used by ulids
+const ulidAlphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+func isGenerator(name string) bool {
+	_, ok := generators[name]
+
+	return ok
+}
+
+// Arguments of generators are numbers, since they are read once instead of
+// for each record
+func (e *expressionParser) parseGenerator(name string) (Insertable, error) {
+	if err := e.expect("("); err != nil {
+		return nil, err
+	}
+
+	args := []int64{}
+	for first := true; e.peek().text != ")"; first = false {
+		if !first {
+			if err := e.expect(","); err != nil {
+				return nil, err
+			}
+		}
+
+		arg := e.next()
+		if arg.kind != numberToken {
+			return nil, fmt.Errorf("The arguments of %s must be integers, but got %s", name, arg.text)
+		}
+
+		literal, err := e.parser.parseLiteral(arg.text)
+		if err != nil {
+			return nil, err
+		}
+
+		value, ok := literal.(*Literal).value.(int64)
+		if !ok {
+			return nil, fmt.Errorf("The arguments of %s must be integers, but got %s", name, arg.text)
+		}
+
+		args = append(args, value)
+	}
+
+	if err := e.expect(")"); err != nil {
+		return nil, err
+	}
+
+	if len(args) > generators[name] {
+		return nil, fmt.Errorf("%s can't take %d arguments", name, len(args))
+	}
+
+	generator := &Generator{name: name, next: 1, step: 1}
+	if len(args) >= 1 {
+		generator.next = args[0]
+	}
+
+	if len(args) == 2 {
+		generator.step = args[1]
+	}
+
+	generator.start = generator.next
+
+	if name != "seq" || e.parser.column == "" {
+		return generator, nil
+	}
+
+	// Each insertion of a table would repeat the keys of the others with a
+	// sequence of its own
+	sequence, ok := e.parser.sequences[e.parser.column]
+	if !ok {
+		e.parser.sequences[e.parser.column] = generator
+		return generator, nil
+	}
+
+	if sequence.start != generator.start || sequence.step != generator.step {
+		return nil, fmt.Errorf(
+			"The insertions of %s declare different sequences: seq(%d, %d) and seq(%d, %d)",
+			e.parser.column,
+			sequence.start,
+			sequence.step,
+			generator.start,
+			generator.step,
+		)
+	}
+
+	return sequence, nil
+}
+
+func (g *Generator) generateValue(context InsertContext) (any, error) {
+	switch g.name {
+	case "uuid":
+		return uuid.NewString(), nil
+	case "ulid":
+		return newULID(time.Now())
+	default:
+		value := g.next
+		g.next += g.step
+
+		return value, nil
+	}
+}
+
+// 48 bits of milliseconds followed by 80 random bits, encoded in 26
+// characters
+func newULID(now time.Time) (string, error) {
+	id := make([]byte, 16)
+	binary.BigEndian.PutUint64(id[:8], uint64(now.UnixMilli())<<16)
+
+	if _, err := rand.Read(id[6:]); err != nil {
+		return "", fmt.Errorf("Could not generate an ulid: %s", err)
+	}
+
+	return encodeULID(id), nil
+}
+
+// The 128 bits are read 5 at a time, with 2 bits of padding at the start
+func encodeULID(id []byte) string {
+	encoded := make([]byte, 26)
+
+	for idx := range encoded {
+		value := 0
+
+		for bit := idx*5 - 2; bit < idx*5+3; bit++ {
+			value <<= 1
+
+			if bit >= 0 && id[bit/8]&(0x80>>(bit%8)) != 0 {
+				value |= 1
+			}
+		}
+
+		encoded[idx] = ulidAlphabet[value]
+	}
+
+	return string(encoded)
+}
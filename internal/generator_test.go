@@ -0,0 +1,158 @@
+package internal
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestGenerator(t *testing.T) {
+	tests := []struct {
+		input      string
+		expected   []any
+		shouldFail bool
+	}{
+		{"seq()", []any{int64(1), int64(2), int64(3)}, false},
+		{"seq(100)", []any{int64(100), int64(101), int64(102)}, false},
+		{"seq(10, -5)", []any{int64(10), int64(5), int64(0)}, false},
+		{"seq(1, 2, 3)", nil, true},
+		{"seq(1.5)", nil, true},
+		{"seq(Start)", nil, true},
+		{"uuid(1)", nil, true},
+	}
+
+	for _, tt := range tests {
+		generator, err := NewParser(nil).parseFieldValue(tt.input)
+
+		if err != nil {
+			if !tt.shouldFail {
+				t.Errorf("Expected %s to be parsed, but got %s", tt.input, err)
+			}
+
+			continue
+		}
+
+		if tt.shouldFail {
+			t.Errorf("Expected %s to fail, but got %v", tt.input, generator)
+		}
+
+		got := []any{}
+		for range tt.expected {
+			value, _ := generator.generateValue(InsertContext{})
+			got = append(got, value)
+		}
+
+		if !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("Expected %v, but got %v", tt.expected, got)
+		}
+	}
+}
+
+func TestUUIDGenerator(t *testing.T) {
+	generator, _ := NewParser(nil).parseFieldValue("uuid()")
+	first, _ := generator.generateValue(InsertContext{})
+	second, _ := generator.generateValue(InsertContext{})
+
+	if _, err := uuid.Parse(first.(string)); err != nil || first == second {
+		t.Errorf("Expected different uuids, but got %v and %v", first, second)
+	}
+}
+
+func TestEncodeULID(t *testing.T) {
+	tests := []struct {
+		id       []byte
+		expected string
+	}{
+		{make([]byte, 16), "00000000000000000000000000"},
+		{bytes.Repeat([]byte{0xff}, 16), "7ZZZZZZZZZZZZZZZZZZZZZZZZZ"},
+	}
+
+	for _, tt := range tests {
+		if got := encodeULID(tt.id); got != tt.expected {
+			t.Errorf("Expected %v, but got %v", tt.expected, got)
+		}
+	}
+
+	// Example of the ulid spec
+	got, _ := newULID(time.UnixMilli(1469918176385))
+	if got[:10] != "01ARYZ6S41" {
+		t.Errorf("Expected the time to be 01ARYZ6S41, but got %v", got[:10])
+	}
+}
+
+func TestGeneratedId(t *testing.T) {
+	tests := []struct {
+		fields   map[string]Insertable
+		expected string
+	}{
+		{map[string]Insertable{"name": &Literal{}}, ""},
+		{map[string]Insertable{"code": &Generator{name: "uuid"}, "name": &Literal{}}, ""},
+		{map[string]Insertable{"code": &Generator{name: "seq"}, "id": &Generator{name: "ulid"}}, "id"},
+		{map[string]Insertable{"id": &Function{name: "pad", args: []Insertable{&Generator{name: "seq"}}}}, "id"},
+		{map[string]Insertable{"id": &Function{name: "pad", args: []Insertable{&Literal{}}}}, ""},
+	}
+
+	for _, tt := range tests {
+		if got := generatedId(tt.fields, "id"); got != tt.expected {
+			t.Errorf("Expected %v, but got %v", tt.expected, got)
+		}
+	}
+}
+
+func TestSequencePerColumn(t *testing.T) {
+	tests := []struct {
+		input      string
+		expected   []any
+		shouldFail bool
+	}{
+		{
+			"house:\n  insertions:\n    - id: seq(10)\n    - id: seq(10)",
+			[]any{int64(10), int64(11), int64(12), int64(13)},
+			false,
+		},
+		{
+			"house:\n  insertions:\n    - id: seq(10) | pad(4, '0')\n    - id: seq(10)",
+			[]any{"0010", int64(11), "0012", int64(13)},
+			false,
+		},
+		{"house:\n  insertions:\n    - id: seq(10)\n    - id: seq(20)", nil, true},
+		{"house:\n  insertions:\n    - id: seq()\n    - id: seq(1, 2)", nil, true},
+	}
+
+	for _, tt := range tests {
+		mapping, err := ReadMapping([]byte(tt.input), nil)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		tables, err := NewParser(mapping).parse()
+
+		if err != nil {
+			if !tt.shouldFail {
+				t.Errorf("Expected %s to be parsed, but got %s", tt.input, err)
+			}
+
+			continue
+		}
+
+		if tt.shouldFail {
+			t.Errorf("Expected %s to fail", tt.input)
+		}
+
+		got := []any{}
+		for range len(tt.expected) / len(tables) {
+			for _, table := range tables {
+				value, _ := table.fields["id"].generateValue(InsertContext{})
+				got = append(got, value)
+			}
+		}
+
+		if !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("Expected %v, but got %v", tt.expected, got)
+		}
+	}
+}
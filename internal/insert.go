@@ -3,171 +3,408 @@ package internal
 import (
 	"context"
 	"database/sql"
-	"encoding/csv"
 	"errors"
 	"fmt"
-	"os"
+	"io"
 	"os/exec"
 	"slices"
 
+	"github.com/marcos-brito/sozza/internal/source"
 	log "github.com/sirupsen/logrus"
 )
 
 type Inserter struct {
-	database            *sql.DB
-	mapping             *Mapping
-	csvPath             string
-	insertionReferences map[string][]int64
-	header              map[string]int
+	database *sql.DB
+	// The name of the database, used to render statements it handles in its
+	// own way
+	dialect string
+	mapping *Mapping
+	// Inputs by the name used in the mapping. Tables with no source read
+	// from the one with an empty name
+	sources map[string]source.Source
+	// Rows inserted for the current record. Skipped insertions are kept as
+	// nil
+	insertionReferences map[string][]*insertedRow
+	// Rows of tables with a natural key, by table and key. They are kept
+	// for the whole run
+	insertedKeys map[string]map[string]*insertedRow
+	// Records inserted and left out by the filters in the whole run
+	inserted int
+	filtered int
+}
+
+// A row inserted for the current record
+type insertedRow struct {
+	id any
+	// Columns read back for references like __table__.column
+	columns map[string]any
 }
 
 // Data to be passed to a Insertable
 type InsertContext struct {
-	header              map[string]int
-	insertionReferences map[string][]int64
-	csvContent          []string
+	record              source.Record
+	insertionReferences map[string][]*insertedRow
+	// References to skipped insertions are NULL instead of an error
+	skippedAsNull bool
+	// Used to look up rows already in the database
+	transaction *sql.Tx
+	// Dialect of the database, for statements written while inserting
+	dialect string
 }
 
 type Insertable interface {
-	generateValue(context InsertContext) (string, error)
+	generateValue(context InsertContext) (any, error)
 }
 
-func newInserter(database *sql.DB, mapping *Mapping, csvPath string) (*Inserter, error) {
-	inserter := &Inserter{
+func newInserter(database *sql.DB, dialect string, mapping *Mapping, sources map[string]source.Source) *Inserter {
+	return &Inserter{
 		database:            database,
+		dialect:             dialect,
 		mapping:             mapping,
-		csvPath:             csvPath,
-		insertionReferences: map[string][]int64{},
+		sources:             sources,
+		insertionReferences: map[string][]*insertedRow{},
+		insertedKeys:        map[string]map[string]*insertedRow{},
 	}
+}
 
-	header, err := inserter.CreateHeader()
+func (i *Inserter) Insert(numberOfLines int) error {
+	ctx := context.Background()
+	parser := NewParser(i.mapping)
+	transaction, err := i.database.BeginTx(ctx, nil)
 
 	if err != nil {
-		return nil, err
+		return fmt.Errorf("Could not initialize the transaction: %s", err)
 	}
 
-	inserter.header = header
-
-	return inserter, nil
-}
-
-func (i *Inserter) CreateHeader() (map[string]int, error) {
-	file, err := os.Open(i.csvPath)
+	log.Info("Parsing field values")
+	tables, err := parser.parse()
+	if err != nil {
+		return err
+	}
 
+	groups, err := groupBySource(tables)
 	if err != nil {
-		return nil, fmt.Errorf("Could not open the csv file: %s", err)
+		return err
 	}
 
-	reader := csv.NewReader(file)
-	line, err := reader.Read()
+	filters := map[string]Insertable{}
+	for name, condition := range i.mapping.Filters {
+		if _, ok := groups[name]; !ok {
+			return fmt.Errorf("The filter of the %s source is not used, since no table reads from it", sourceName(name))
+		}
 
-	if err != nil {
-		return nil, fmt.Errorf("Could not read the csv file: %s", err)
+		if filters[name], err = parser.parseFilter(name, condition); err != nil {
+			return fmt.Errorf("Error parsing the filter of the %s source: %s", sourceName(name), err)
+		}
 	}
 
-	header := map[string]int{}
-	for idx, field := range line {
-		header[field] = idx
+	names := []string{}
+	for name := range groups {
+		names = append(names, name)
 	}
+	slices.Sort(names)
 
-	return header, nil
-}
+	for _, name := range names {
+		input, ok := i.sources[name]
 
-func (i *Inserter) Insert(numberOfLines int) error {
-	ctx := context.Background()
-	parser := NewParser(*i.mapping)
-	transaction, err := i.database.BeginTx(ctx, nil)
+		if !ok {
+			return fmt.Errorf("No input was given for the %s source", sourceName(name))
+		}
 
-	if err != nil {
-		return fmt.Errorf("Could not initialize the transaction: %s", err)
+		err = i.insertFromSource(input, groups[name], filters[name], transaction, numberOfLines)
+		if err != nil {
+			return err
+		}
 	}
 
-	log.Info("Parsing field values")
-	tables, err := parser.parse()
+	log.Infof("Inserted %d records and filtered %d", i.inserted, i.filtered)
+
+	err = transaction.Commit()
 	if err != nil {
-		return err
+		return errors.New("Commit failed. Changes not made")
 	}
+
+	return nil
+}
+
+// Inserts the tables reading the records of a single source. Records not
+// matching the filter are skipped and don't count as inserted lines
+func (i *Inserter) insertFromSource(
+	input source.Source,
+	tables []Table,
+	filter Insertable,
+	transaction *sql.Tx,
+	numberOfLines int,
+) error {
 	log.Info("Sorting mapped tables")
 	SortInsertions(tables)
 
 	log.Info("Creating prepared statements")
-	statements, err := createStatements(tables, transaction)
+	statements, err := createStatements(tables, i.dialect, transaction)
 	if err != nil {
 		return err
 	}
 
-	csvFile, err := os.Open(i.csvPath)
-	if err != nil {
-		return fmt.Errorf("Could not open %s: %s", i.csvPath, err)
-	}
-
-	reader := csv.NewReader(csvFile)
-	// Ignore the first line
-	_, err = reader.Read()
-
-	if err != nil {
-		return fmt.Errorf("Could not read %s: %s", i.csvPath, err)
-	}
-
 	log.Info("Executing generated querys")
 	line := 0
-	for {
-		record, err := reader.Read()
+	filtered := 0
+	for line < numberOfLines {
+		record, err := input.Next()
 
-		if record == nil || line == numberOfLines {
+		if err == io.EOF {
 			break
 		}
 
 		if err != nil {
-			return fmt.Errorf("Could not read %s: %s", i.csvPath, err)
+			return fmt.Errorf("Could not read the input: %s", err)
 		}
 
 		context := InsertContext{
-			header:              i.header,
+			record:              record,
 			insertionReferences: i.insertionReferences,
-			csvContent:          record,
+			skippedAsNull:       i.mapping.Skipped == "null",
+			transaction:         transaction,
+			dialect:             i.dialect,
 		}
 
-		for idx, statement := range statements {
-			values, err := tables[idx].buildValues(context)
+		if filter != nil {
+			value, err := filter.generateValue(context)
 
 			if err != nil {
-				return err
+				return fmt.Errorf("Error on record %d: Error evaluating the filter: %s", line+filtered+1, err)
 			}
-			result, err := transaction.Stmt(statement).Exec(values...)
 
-			if err != nil {
-				return fmt.Errorf(
-					"Could not execute %s with values %s: %s ",
-					tables[idx].createStatment(),
-					values,
-					err,
-				)
+			if !isTruthy(value) {
+				filtered++
+				continue
 			}
+		}
 
-			id, err := result.LastInsertId()
+		for idx, statement := range statements {
+			contexts, err := tables[idx].explodeContext(context)
 
 			if err != nil {
-				return fmt.Errorf("Could not get the insert id for %s: %s", tables[idx].name, err)
+				return fmt.Errorf("Error on record %d: %s", line+filtered+1, err)
 			}
 
-			i.insertionReferences[tables[idx].name] = append(
-				i.insertionReferences[tables[idx].name],
-				id,
-			)
+			for _, rowContext := range contexts {
+				if err := i.insertRow(tables[idx], statement, rowContext, transaction); err != nil {
+					return fmt.Errorf("Error on record %d: %s", line+filtered+1, err)
+				}
+			}
 		}
 
-		i.insertionReferences = map[string][]int64{}
+		i.insertionReferences = map[string][]*insertedRow{}
 		line++
 	}
-	err = transaction.Commit()
+
+	i.inserted += line
+	i.filtered += filtered
+
+	return nil
+}
+
+// Inserts a single row of the table, keeping its id to be referenced
+func (i *Inserter) insertRow(table Table, statement *sql.Stmt, context InsertContext, transaction *sql.Tx) error {
+	insert, err := table.shouldInsert(context)
+
 	if err != nil {
-		return errors.New("Commit failed. Changes not made")
+		return err
+	}
+
+	if !insert {
+		log.Tracef("Skipping %s", table.name)
+		i.addReference(table, nil)
+		return nil
+	}
+
+	values, err := table.buildValues(context)
+
+	if err != nil {
+		return err
+	}
+
+	key, hasKey := table.naturalKey(values)
+	if row, ok := i.insertedKeys[table.name][key]; hasKey && ok {
+		log.Tracef("Reusing %s %s", table.name, key)
+		i.addReference(table, row)
+		return nil
+	}
+
+	row, err := i.execute(table, statement, values, transaction)
+
+	if err != nil {
+		return err
+	}
+
+	i.addReference(table, row)
+
+	if hasKey {
+		if _, ok := i.insertedKeys[table.name]; !ok {
+			i.insertedKeys[table.name] = map[string]*insertedRow{}
+		}
+
+		i.insertedKeys[table.name][key] = row
 	}
 
 	return nil
 }
 
+// Aliased insertions are kept under table.alias as well
+func (i *Inserter) addReference(table Table, row *insertedRow) {
+	i.insertionReferences[table.name] = append(i.insertionReferences[table.name], row)
+
+	if table.alias != "" {
+		name := table.name + "." + table.alias
+		i.insertionReferences[name] = append(i.insertionReferences[name], row)
+	}
+}
+
+// Runs the insert and reads the id of the row, along with the columns other
+// tables reference. They come from RETURNING when other tables need them
+func (i *Inserter) execute(table Table, statement *sql.Stmt, values []any, transaction *sql.Tx) (*insertedRow, error) {
+	if table.returnsRow(i.dialect) {
+		columns := append([]string{table.idColumn(i.dialect)}, table.returning...)
+		row, err := scanRow(transaction.Stmt(statement).QueryRow(values...), columns)
+
+		// Conflicts ignored return nothing, so the row is read afterwards
+		if errors.Is(err, sql.ErrNoRows) && table.conflict.handled() {
+			return i.selectRow(table, table.conflict.On, table.conflictValues(values), transaction)
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("Could not execute %s with values %s: %s ", table.createStatment(i.dialect), values, err)
+		}
+
+		return row, nil
+	}
+
+	result, err := transaction.Stmt(statement).Exec(values...)
+
+	if err != nil {
+		return nil, fmt.Errorf(
+			"Could not execute %s with values %s: %s ",
+			table.createStatment(i.dialect),
+			values,
+			err,
+		)
+	}
+
+	// Ids of rows no one references are not needed
+	if !table.referenced {
+		return &insertedRow{}, nil
+	}
+
+	// When conflicts are handled the statement may not insert anything, so
+	// the row is the one having the values of the conflict
+	if table.conflict.handled() {
+		return i.selectRow(table, table.conflict.On, table.conflictValues(values), transaction)
+	}
+
+	id, err := insertId(table, values, result)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &insertedRow{id: id}, nil
+}
+
+// Generated ids are already known, so the database is not asked for them
+func insertId(table Table, values []any, result sql.Result) (any, error) {
+	if table.generatedId != "" {
+		return values[slices.Index(table.order, table.generatedId)], nil
+	}
+
+	id, err := result.LastInsertId()
+
+	if err != nil {
+		return nil, fmt.Errorf("Could not get the insert id for %s: %s", table.name, err)
+	}
+
+	return id, nil
+}
+
+// Reads the id and the referenced columns of the row matching values
+func (i *Inserter) selectRow(table Table, where []string, values []any, transaction *sql.Tx) (*insertedRow, error) {
+	columns := append([]string{table.idColumn(i.dialect)}, table.returning...)
+	statement := bindParams(table.selectStatement(columns, where), i.dialect)
+	row, err := scanRow(transaction.QueryRow(statement, values...), columns)
+
+	if err != nil {
+		return nil, fmt.Errorf("Could not read the inserted %s: %s", table.name, err)
+	}
+
+	return row, nil
+}
+
+// The first column is the id
+func scanRow(row *sql.Row, columns []string) (*insertedRow, error) {
+	values := make([]any, len(columns))
+	destinations := []any{}
+
+	for idx := range values {
+		destinations = append(destinations, &values[idx])
+	}
+
+	if err := row.Scan(destinations...); err != nil {
+		return nil, err
+	}
+
+	inserted := &insertedRow{id: values[0], columns: map[string]any{}}
+	for idx, column := range columns[1:] {
+		value := values[idx+1]
+
+		// Some drivers scan text as bytes
+		if text, ok := value.([]byte); ok {
+			value = string(text)
+		}
+
+		inserted.columns[column] = value
+	}
+
+	return inserted, nil
+}
+
+// Tables are inserted along with the other tables reading the same source.
+// A table can only reference tables of its own source, since references
+// live only while a single record is being inserted
+func groupBySource(tables []Table) (map[string][]Table, error) {
+	groups := map[string][]Table{}
+	sources := map[string]string{}
+
+	for _, table := range tables {
+		groups[table.source] = append(groups[table.source], table)
+		sources[table.name] = table.source
+	}
+
+	for _, table := range tables {
+		for _, reference := range table.findReferences() {
+			referenced, ok := sources[reference.referenceTable]
+
+			if ok && referenced != table.source {
+				return nil, fmt.Errorf(
+					"%s reads from the %s source, but references %s, which reads from %s",
+					table.name,
+					sourceName(table.source),
+					reference.referenceTable,
+					sourceName(referenced),
+				)
+			}
+		}
+	}
+
+	return groups, nil
+}
+
+func sourceName(name string) string {
+	if name == "" {
+		return "default"
+	}
+
+	return name
+}
+
 func SortInsertions(tables []Table) {
 	slices.SortFunc(tables, func(a Table, b Table) int {
 		references := a.findReferences()
@@ -182,71 +419,104 @@ func SortInsertions(tables []Table) {
 	})
 }
 
-func createStatements(tables []Table, transaction *sql.Tx) ([]*sql.Stmt, error) {
+func createStatements(tables []Table, dialect string, transaction *sql.Tx) ([]*sql.Stmt, error) {
 	statements := []*sql.Stmt{}
 
 	for _, table := range tables {
 		log.Debugf("Creating prepared statement for %s", table.name)
-		statement, err := transaction.Prepare(table.createStatment())
+		statement, err := transaction.Prepare(table.createStatment(dialect))
 
 		if err != nil {
 			return nil, fmt.Errorf("Could not create prepared statment for %s: %s", table.name, err)
 		}
 
-		log.Tracef("Created %s", table.createStatment())
+		log.Tracef("Created %s", table.createStatment(dialect))
 		statements = append(statements, statement)
 	}
 	return statements, nil
 }
 
-func (f *FormatedInput) generateValue(context InsertContext) (string, error) {
+func (f *FormatedInput) generateValue(context InsertContext) (any, error) {
 	params := []string{}
 
 	for _, param := range f.params {
-		index, ok := context.header[param]
+		value, err := context.record.Get(param)
 
-		if !ok {
-			return "", fmt.Errorf("Csv file does not have a %s field", param)
+		if err != nil {
+			return nil, err
 		}
 
-		params = append(params, context.csvContent[index])
+		params = append(params, toString(value))
 	}
 
 	out, err := exec.Command(f.scriptPath, params...).Output()
 	if err != nil {
-		return "", fmt.Errorf("Error executing formatting script: %s", err)
+		return nil, fmt.Errorf("Error executing formatting script: %s", err)
 	}
 
 	return string(out), nil
 }
 
-func (t *TableReference) generateValue(context InsertContext) (string, error) {
-	references, ok := context.insertionReferences[t.referenceTable]
+func (t *TableReference) generateValue(context InsertContext) (any, error) {
+	name := t.referenceTable
+	if t.alias != "" {
+		name += "." + t.alias
+	}
+
+	references, ok := context.insertionReferences[name]
 
 	if !ok {
-		return "", fmt.Errorf("Tried to reference %s, but it was never inserted", t.referenceTable)
+		return nil, fmt.Errorf("Tried to reference %s, but it was never inserted", name)
 	}
 
 	if t.insertion > len(references)-1 {
-		return "", fmt.Errorf(
+		return nil, fmt.Errorf(
 			"%s had %d insertions, but tried to get the %d nth",
-			t.referenceTable,
+			name,
 			len(references),
 			t.insertion,
 		)
 	}
 
-	return fmt.Sprint(references[t.insertion]), nil
-}
+	row := references[t.insertion]
 
-func (t *RegularInsertion) generateValue(context InsertContext) (string, error) {
-	index, ok := context.header[t.value]
+	if row == nil && !context.skippedAsNull {
+		return nil, fmt.Errorf("Tried to reference %s %d, but it was skipped", name, t.insertion)
+	}
 
-	if !ok {
-		return "", fmt.Errorf("Csv file does not have a %s field", t.value)
+	if row == nil {
+		return nil, nil
+	}
+
+	if t.column == "" {
+		return row.id, nil
 	}
 
-	value := context.csvContent[index]
+	value, ok := row.columns[t.column]
+
+	if !ok {
+		return nil, fmt.Errorf("The %s column of %s was not read", t.column, name)
+	}
 
 	return value, nil
 }
+
+func (t *RegularInsertion) generateValue(context InsertContext) (any, error) {
+	return context.record.Get(t.value)
+}
+
+func (l *Literal) generateValue(context InsertContext) (any, error) {
+	return l.value, nil
+}
+
+// Values from typed sources may be anything, but scripts only take strings
+func toString(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case []byte:
+		return string(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,480 @@
+package internal
+
+import (
+	"database/sql"
+	"io"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/marcos-brito/sozza/internal/connector"
+	"github.com/marcos-brito/sozza/internal/source"
+)
+
+// Gives the records it was created with
+type recordSource struct {
+	records []source.Record
+}
+
+func (r *recordSource) Next() (source.Record, error) {
+	if len(r.records) == 0 {
+		return nil, io.EOF
+	}
+
+	record := r.records[0]
+	r.records = r.records[1:]
+
+	return record, nil
+}
+
+func (r *recordSource) Close() error {
+	return nil
+}
+
+type testDatabase struct {
+	database *sql.DB
+	dialect  string
+	// Type of the id columns, which the database fills
+	idType string
+}
+
+// Databases the inserter runs against. Postgres is only used when
+// SOZZA_POSTGRES_URL points to one, since its tables are dropped
+func testDatabases(t *testing.T) []testDatabase {
+	sqlite := &connector.Sqlite{}
+	database := sqlite.Connect(":memory:")
+	// Each connection would get its own database otherwise
+	database.SetMaxOpenConns(1)
+	t.Cleanup(func() { database.Close() })
+
+	databases := []testDatabase{{database, sqlite.Dialect(), "INTEGER PRIMARY KEY"}}
+
+	if url := os.Getenv("SOZZA_POSTGRES_URL"); url != "" {
+		postgres := &connector.Postgres{}
+		database := postgres.Connect(url)
+		t.Cleanup(func() { database.Close() })
+
+		databases = append(databases, testDatabase{database, postgres.Dialect(), "SERIAL PRIMARY KEY"})
+	}
+
+	return databases
+}
+
+func execAll(t *testing.T, database *sql.DB, statements ...string) {
+	for _, statement := range statements {
+		if _, err := database.Exec(statement); err != nil {
+			t.Fatalf("Could not execute %s: %s", statement, err)
+		}
+	}
+}
+
+func queryAll(t *testing.T, database *sql.DB, query string) [][]any {
+	rows, err := database.Query(query)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer rows.Close()
+
+	columns, _ := rows.Columns()
+	got := [][]any{}
+	for rows.Next() {
+		values := make([]any, len(columns))
+		destinations := []any{}
+
+		for idx := range values {
+			destinations = append(destinations, &values[idx])
+		}
+
+		if err := rows.Scan(destinations...); err != nil {
+			t.Fatal(err)
+		}
+
+		for idx, value := range values {
+			if text, ok := value.([]byte); ok {
+				values[idx] = string(text)
+			}
+		}
+
+		got = append(got, values)
+	}
+
+	return got
+}
+
+func TestInsertConflicts(t *testing.T) {
+	records := func() source.Source {
+		return &recordSource{records: []source.Record{
+			mapRecord{"Email": "ann@mail.com", "Name": "Ann", "Title": "One"},
+			mapRecord{"Email": "bob@mail.com", "Name": "Bob", "Title": "Two"},
+			mapRecord{"Email": "ann@mail.com", "Name": "Ann", "Title": "Three"},
+		}}
+	}
+
+	tests := []struct {
+		do       string
+		expected [][]any
+	}{
+		{
+			"ignore",
+			[][]any{
+				{"One", "ann@mail.com", "Old"},
+				{"Two", "bob@mail.com", "Bob"},
+				{"Three", "ann@mail.com", "Old"},
+			},
+		},
+		{
+			"update",
+			[][]any{
+				{"One", "ann@mail.com", "Ann"},
+				{"Two", "bob@mail.com", "Bob"},
+				{"Three", "ann@mail.com", "Ann"},
+			},
+		},
+	}
+
+	for _, database := range testDatabases(t) {
+		for _, tt := range tests {
+			execAll(
+				t,
+				database.database,
+				"DROP TABLE IF EXISTS posts",
+				"DROP TABLE IF EXISTS users",
+				"CREATE TABLE users (id "+database.idType+", email TEXT UNIQUE, name TEXT)",
+				"CREATE TABLE posts (id "+database.idType+", user_id INTEGER, title TEXT)",
+				"INSERT INTO users (email, name) VALUES ('ann@mail.com', 'Old')",
+			)
+
+			mapping, err := ReadMapping([]byte(`
+users:
+  conflict:
+    on: [email]
+    do: `+tt.do+`
+  insertions:
+    - email: Email
+      name: Name
+posts:
+  insertions:
+    - user_id: __users__
+      title: Title
+`), nil)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			inserter := newInserter(database.database, database.dialect, mapping, map[string]source.Source{"": records()})
+			if err := inserter.Insert(10); err != nil {
+				t.Fatalf("Expected %s to insert with %s, but got %s", database.dialect, tt.do, err)
+			}
+
+			got := queryAll(t, database.database, "SELECT p.title, u.email, u.name FROM posts p JOIN users u ON u.id = p.user_id ORDER BY p.id")
+
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Expected %v, but got %v with %s", tt.expected, got, database.dialect)
+			}
+		}
+	}
+}
+
+func TestInsertReturning(t *testing.T) {
+	for _, database := range testDatabases(t) {
+		execAll(
+			t,
+			database.database,
+			"DROP TABLE IF EXISTS users",
+			"DROP TABLE IF EXISTS house",
+			"CREATE TABLE house (id "+database.idType+", color TEXT, slug TEXT DEFAULT 'no-slug')",
+			"CREATE TABLE users (id "+database.idType+", name TEXT, house_id INTEGER, house_color TEXT, house_slug TEXT)",
+		)
+
+		mapping, err := ReadMapping([]byte(`
+house:
+  insertions:
+    - color: Color
+users:
+  insertions:
+    - name: Name
+      house_id: __house__
+      house_color: upper(__house__.color)
+      house_slug: __house__.slug
+`), nil)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		records := &recordSource{records: []source.Record{
+			mapRecord{"Color": "red", "Name": "Ann"},
+			mapRecord{"Color": "blue", "Name": "Bob"},
+		}}
+
+		inserter := newInserter(database.database, database.dialect, mapping, map[string]source.Source{"": records})
+		if err := inserter.Insert(10); err != nil {
+			t.Fatalf("Expected %s to insert, but got %s", database.dialect, err)
+		}
+
+		got := queryAll(t, database.database, "SELECT u.name, h.color, u.house_color, u.house_slug FROM users u JOIN house h ON h.id = u.house_id ORDER BY u.id")
+		expected := [][]any{
+			{"Ann", "red", "RED", "no-slug"},
+			{"Bob", "blue", "BLUE", "no-slug"},
+		}
+
+		if !reflect.DeepEqual(got, expected) {
+			t.Errorf("Expected %v, but got %v with %s", expected, got, database.dialect)
+		}
+	}
+}
+
+func TestInsertIdColumns(t *testing.T) {
+	for _, database := range testDatabases(t) {
+		execAll(
+			t,
+			database.database,
+			"DROP TABLE IF EXISTS user_tags",
+			"DROP TABLE IF EXISTS users",
+			"DROP TABLE IF EXISTS tags",
+			"CREATE TABLE users (id "+database.idType+", name TEXT)",
+			"CREATE TABLE tags (code TEXT PRIMARY KEY, name TEXT)",
+			"CREATE TABLE user_tags (user_id INTEGER, tag_code TEXT, UNIQUE (user_id, tag_code))",
+		)
+
+		mapping, err := ReadMapping([]byte(`
+users:
+  insertions:
+    - name: Name
+tags:
+  id: code
+  insertions:
+    - code: lower(Tag)
+      name: Tag
+user_tags:
+  conflict:
+    on: [user_id, tag_code]
+    do: ignore
+  insertions:
+    - user_id: __users__
+      tag_code: __tags__
+`), nil)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		records := &recordSource{records: []source.Record{
+			mapRecord{"Name": "Ann", "Tag": "Admin"},
+			mapRecord{"Name": "Bob", "Tag": "Guest"},
+		}}
+
+		inserter := newInserter(database.database, database.dialect, mapping, map[string]source.Source{"": records})
+		if err := inserter.Insert(10); err != nil {
+			t.Fatalf("Expected %s to insert, but got %s", database.dialect, err)
+		}
+
+		got := queryAll(t, database.database, "SELECT u.name, t.name FROM user_tags ut JOIN users u ON u.id = ut.user_id JOIN tags t ON t.code = ut.tag_code ORDER BY u.id")
+		expected := [][]any{
+			{"Ann", "Admin"},
+			{"Bob", "Guest"},
+		}
+
+		if !reflect.DeepEqual(got, expected) {
+			t.Errorf("Expected %v, but got %v with %s", expected, got, database.dialect)
+		}
+	}
+}
+
+func TestInsertNaturalKeys(t *testing.T) {
+	for _, database := range testDatabases(t) {
+		execAll(
+			t,
+			database.database,
+			"DROP TABLE IF EXISTS users",
+			"DROP TABLE IF EXISTS house",
+			"CREATE TABLE house (id "+database.idType+", color TEXT, size TEXT)",
+			"CREATE TABLE users (id "+database.idType+", name TEXT, house_id INTEGER)",
+		)
+
+		mapping, err := ReadMapping([]byte(`
+house:
+  key: [color, size]
+  insertions:
+    - color: Color
+      size: Size
+users:
+  insertions:
+    - name: Name
+      house_id: __house__
+`), nil)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		records := &recordSource{records: []source.Record{
+			mapRecord{"Color": "red", "Size": "3", "Name": "Ann"},
+			mapRecord{"Color": "blue", "Size": "3", "Name": "Bob"},
+			mapRecord{"Color": "red", "Size": "3", "Name": "Cid"},
+		}}
+
+		inserter := newInserter(database.database, database.dialect, mapping, map[string]source.Source{"": records})
+		if err := inserter.Insert(10); err != nil {
+			t.Fatalf("Expected %s to insert, but got %s", database.dialect, err)
+		}
+
+		houses := queryAll(t, database.database, "SELECT id, color FROM house ORDER BY id")
+		expectedHouses := [][]any{
+			{int64(1), "red"},
+			{int64(2), "blue"},
+		}
+
+		if !reflect.DeepEqual(houses, expectedHouses) {
+			t.Errorf("Expected %v, but got %v with %s", expectedHouses, houses, database.dialect)
+		}
+
+		users := queryAll(t, database.database, "SELECT name, house_id FROM users ORDER BY id")
+		expectedUsers := [][]any{
+			{"Ann", int64(1)},
+			{"Bob", int64(2)},
+			{"Cid", int64(1)},
+		}
+
+		if !reflect.DeepEqual(users, expectedUsers) {
+			t.Errorf("Expected %v, but got %v with %s", expectedUsers, users, database.dialect)
+		}
+	}
+}
+
+func TestInsertFilters(t *testing.T) {
+	tests := []struct {
+		filter     string
+		inserted   int
+		filtered   int
+		shouldFail bool
+	}{
+		{"", 5, 0, false},
+		{`filter: Country == "BR"`, 0, 0, true},
+		{"filter:\n  houses: Size > 2", 4, 1, false},
+		{"filter:\n  users: Country == 'BR'\n  houses: Size > 2", 3, 2, false},
+		{"filter:\n  pets: Size > 2", 0, 0, true},
+		{"filter:\n  houses: Country == 'BR'", 0, 0, true},
+	}
+
+	for _, tt := range tests {
+		database := testDatabases(t)[0]
+		execAll(
+			t,
+			database.database,
+			"DROP TABLE IF EXISTS users",
+			"DROP TABLE IF EXISTS houses",
+			"CREATE TABLE users (id "+database.idType+", name TEXT, country TEXT)",
+			"CREATE TABLE houses (id "+database.idType+", size INTEGER)",
+		)
+
+		mapping, err := ReadMapping([]byte(tt.filter+`
+users:
+  source: users
+  insertions:
+    - name: Name
+      country: Country
+houses:
+  source: houses
+  insertions:
+    - size: Size
+`), nil)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		sources := map[string]source.Source{
+			"users": &recordSource{records: []source.Record{
+				mapRecord{"Name": "Ann", "Country": "BR"},
+				mapRecord{"Name": "Bob", "Country": "PT"},
+				mapRecord{"Name": "Cid", "Country": "BR"},
+			}},
+			"houses": &recordSource{records: []source.Record{
+				mapRecord{"Size": "3"},
+				mapRecord{"Size": "1"},
+			}},
+		}
+
+		inserter := newInserter(database.database, database.dialect, mapping, sources)
+		err = inserter.Insert(10)
+
+		if err != nil {
+			if !tt.shouldFail {
+				t.Errorf("Expected %s to insert, but got %s", tt.filter, err)
+			}
+
+			continue
+		}
+
+		if tt.shouldFail {
+			t.Errorf("Expected %s to fail", tt.filter)
+		}
+
+		if inserter.inserted != tt.inserted || inserter.filtered != tt.filtered {
+			t.Errorf("Expected %d inserted and %d filtered, but got %d and %d", tt.inserted, tt.filtered, inserter.inserted, inserter.filtered)
+		}
+
+		rows := queryAll(t, database.database, "SELECT (SELECT count(*) FROM users) + (SELECT count(*) FROM houses)")
+		if rows[0][0] != int64(tt.inserted) {
+			t.Errorf("Expected %d rows, but got %v", tt.inserted, rows[0][0])
+		}
+	}
+}
+
+func TestInsertGeneratedColumns(t *testing.T) {
+	for _, database := range testDatabases(t) {
+		execAll(
+			t,
+			database.database,
+			"DROP TABLE IF EXISTS items",
+			"DROP TABLE IF EXISTS orders",
+			"DROP TABLE IF EXISTS carts",
+			"CREATE TABLE orders (id "+database.idType+", code TEXT)",
+			"CREATE TABLE carts (id TEXT PRIMARY KEY, code TEXT)",
+			"CREATE TABLE items (id "+database.idType+", order_id INTEGER, cart_id TEXT, name TEXT)",
+		)
+
+		mapping, err := ReadMapping([]byte(`
+orders:
+  insertions:
+    - code: uuid()
+carts:
+  insertions:
+    - id: seq(7) | pad(3, '0')
+      code: ulid()
+items:
+  insertions:
+    - order_id: __orders__
+      cart_id: __carts__
+      name: Name
+`), nil)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		records := &recordSource{records: []source.Record{
+			mapRecord{"Name": "Pen"},
+			mapRecord{"Name": "Ink"},
+		}}
+
+		inserter := newInserter(database.database, database.dialect, mapping, map[string]source.Source{"": records})
+		if err := inserter.Insert(10); err != nil {
+			t.Fatalf("Expected %s to insert, but got %s", database.dialect, err)
+		}
+
+		got := queryAll(t, database.database, "SELECT i.name, i.order_id, i.cart_id, length(o.code) FROM items i JOIN orders o ON o.id = i.order_id ORDER BY i.id")
+		expected := [][]any{
+			{"Pen", int64(1), "007", int64(36)},
+			{"Ink", int64(2), "008", int64(36)},
+		}
+
+		if !reflect.DeepEqual(got, expected) {
+			t.Errorf("Expected %v, but got %v with %s", expected, got, database.dialect)
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package internal
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type tokenKind int
+
+const (
+	identToken tokenKind = iota
+	// Column names quoted with backticks
+	columnToken
+	stringToken
+	numberToken
+	symbolToken
+)
+
+type token struct {
+	kind tokenKind
+	text string
+}
+
+const symbols = "(),|"
+
+// Operators used in conditions. The longer ones come first, so <= is not
+// read as < followed by =
+var operators = []string{"==", "!=", "<=", ">=", "&&", "||", "<", ">", "!", "="}
+
+// Splits the text of a field value into tokens. Identifiers are column
+// names, unless followed by a parenthesis, and may be quoted with backticks
+// when they have spaces or other symbols
+func tokenize(input string) ([]token, error) {
+	tokens := []token{}
+	runes := []rune(input)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case readOperator(runes[i:]) != "":
+			operator := readOperator(runes[i:])
+			tokens = append(tokens, token{kind: symbolToken, text: operator})
+			i += len([]rune(operator))
+		case strings.ContainsRune(symbols, r):
+			tokens = append(tokens, token{kind: symbolToken, text: string(r)})
+			i++
+		case r == '\'' || r == '"' || r == '`':
+			text, end, err := readQuoted(runes, i)
+
+			if err != nil {
+				return nil, err
+			}
+
+			kind := stringToken
+			if r == '`' {
+				kind = columnToken
+			}
+
+			tokens = append(tokens, token{kind: kind, text: text})
+			i = end
+		case unicode.IsDigit(r) || (r == '-' && i+1 < len(runes) && unicode.IsDigit(runes[i+1])):
+			end := i + 1
+			for end < len(runes) && (unicode.IsDigit(runes[end]) || runes[end] == '.') {
+				end++
+			}
+
+			tokens = append(tokens, token{kind: numberToken, text: string(runes[i:end])})
+			i = end
+		case isIdentRune(r):
+			end := i + 1
+			for end < len(runes) && isIdentRune(runes[end]) {
+				end++
+			}
+
+			tokens = append(tokens, token{kind: identToken, text: string(runes[i:end])})
+			i = end
+		default:
+			return nil, fmt.Errorf("Unexpected %c at position %d", r, i)
+		}
+	}
+
+	return tokens, nil
+}
+
+func readOperator(runes []rune) string {
+	for _, operator := range operators {
+		if strings.HasPrefix(string(runes[:min(len(runes), 2)]), operator) {
+			return operator
+		}
+	}
+
+	return ""
+}
+
+func isIdentRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '.' || r == '$'
+}
+
+// Reads a quoted text starting at start. Quotes inside it are escaped with
+// a backslash. Returns the text without quotes and where it ends
+func readQuoted(runes []rune, start int) (string, int, error) {
+	quote := runes[start]
+	text := strings.Builder{}
+
+	for i := start + 1; i < len(runes); i++ {
+		switch runes[i] {
+		case '\\':
+			if i+1 < len(runes) {
+				i++
+			}
+
+			text.WriteRune(runes[i])
+		case quote:
+			return text.String(), i + 1, nil
+		default:
+			text.WriteRune(runes[i])
+		}
+	}
+
+	return "", 0, fmt.Errorf("Missing closing %c", quote)
+}
+
+// Splits the text on separator, ignoring the ones inside quotes or
+// parentheses
+func splitTopLevel(input string, separator rune) []string {
+	parts := []string{}
+	depth := 0
+	escaped := false
+	var quote rune
+	last := 0
+
+	for i, r := range input {
+		switch {
+		case escaped:
+			escaped = false
+		case quote != 0:
+			if r == '\\' {
+				escaped = true
+			} else if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"' || r == '`':
+			quote = r
+		case r == '(':
+			depth++
+		case r == ')':
+			depth--
+		case r == separator && depth == 0:
+			parts = append(parts, input[last:i])
+			last = i + utf8.RuneLen(r)
+		}
+	}
+
+	return append(parts, input[last:])
+}
@@ -0,0 +1,113 @@
+package internal
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		input      string
+		expected   []token
+		shouldFail bool
+	}{
+		{
+			"lower",
+			[]token{{identToken, "lower"}},
+			false,
+		},
+		{
+			"replace(' ', \"_\")",
+			[]token{
+				{identToken, "replace"},
+				{symbolToken, "("},
+				{stringToken, " "},
+				{symbolToken, ","},
+				{stringToken, "_"},
+				{symbolToken, ")"},
+			},
+			false,
+		},
+		{
+			"concat(`First Name`, address.city, $2, -1.5)",
+			[]token{
+				{identToken, "concat"},
+				{symbolToken, "("},
+				{columnToken, "First Name"},
+				{symbolToken, ","},
+				{identToken, "address.city"},
+				{symbolToken, ","},
+				{identToken, "$2"},
+				{symbolToken, ","},
+				{numberToken, "-1.5"},
+				{symbolToken, ")"},
+			},
+			false,
+		},
+		{
+			`'it\'s'`,
+			[]token{{stringToken, "it's"}},
+			false,
+		},
+		{
+			"!Active && Age >= 18 || Name != ''",
+			[]token{
+				{symbolToken, "!"},
+				{identToken, "Active"},
+				{symbolToken, "&&"},
+				{identToken, "Age"},
+				{symbolToken, ">="},
+				{numberToken, "18"},
+				{symbolToken, "||"},
+				{identToken, "Name"},
+				{symbolToken, "!="},
+				{stringToken, ""},
+			},
+			false,
+		},
+		{
+			"'unclosed",
+			nil,
+			true,
+		},
+		{
+			"a + b",
+			nil,
+			true,
+		},
+	}
+
+	for _, tt := range tests {
+		got, err := tokenize(tt.input)
+
+		if tt.shouldFail && err == nil {
+			t.Errorf("Expected \"%s\", to fail but got %v", tt.input, got)
+			continue
+		}
+
+		if !reflect.DeepEqual(tt.expected, got) && !tt.shouldFail {
+			t.Errorf("Expected %v, but got %v: %s", tt.expected, got, tt.input)
+		}
+	}
+}
+
+func TestSplitTopLevel(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"Email", []string{"Email"}},
+		{"Email | trim | lower", []string{"Email ", " trim ", " lower"}},
+		{"Email | replace('|', '-')", []string{"Email ", " replace('|', '-')"}},
+		{"concat(A, B | lower) | upper", []string{"concat(A, B | lower) ", " upper"}},
+		{`'a\'|b' | trim`, []string{`'a\'|b' `, " trim"}},
+	}
+
+	for _, tt := range tests {
+		got := splitTopLevel(tt.input, '|')
+
+		if !reflect.DeepEqual(tt.expected, got) {
+			t.Errorf("Expected %q, but got %q", tt.expected, got)
+		}
+	}
+}
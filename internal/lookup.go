@@ -0,0 +1,216 @@
+package internal
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Reads a column of a row already in the database, like
+// lookup(countries.id, code = CountryCode). Results are cached, so each
+// value is only queried once
+type Lookup struct {
+	table  string
+	column string
+	// Columns of the table matched against each value
+	columns []string
+	values  []Insertable
+	// What happens when no row matches: error, null or insert
+	missing string
+	cache   map[string]any
+}
+
+const lookupName = "lookup"
+
+var missingModes = []string{"error", "null", "insert"}
+
+// Parses the arguments of lookup after its name. The last one may be how
+// missing rows are handled
+func (e *expressionParser) parseLookup() (Insertable, error) {
+	if err := e.expect("("); err != nil {
+		return nil, err
+	}
+
+	target := e.next()
+	table, column, found := strings.Cut(target.text, ".")
+
+	if target.kind != identToken || !found || table == "" || column == "" {
+		return nil, fmt.Errorf("Expected table.column, but found %s", target.text)
+	}
+
+	lookup := &Lookup{table: table, column: column, missing: "error", cache: map[string]any{}}
+
+	for e.peek().text != ")" {
+		if err := e.expect(","); err != nil {
+			return nil, err
+		}
+
+		if current := e.peek(); current.kind == stringToken {
+			e.next()
+			if !isMissingMode(current.text) {
+				return nil, fmt.Errorf("Missing rows must be handled with one of %s, but got %s", strings.Join(missingModes, ", "), current.text)
+			}
+
+			lookup.missing = current.text
+			break
+		}
+
+		name := e.next()
+		if name.kind != identToken && name.kind != columnToken {
+			return nil, fmt.Errorf("Expected a column of %s, but found %s", table, name.text)
+		}
+
+		if err := e.expect("="); err != nil {
+			return nil, err
+		}
+
+		value, err := e.parseOperand()
+
+		if err != nil {
+			return nil, err
+		}
+
+		column := name.text
+		if name.kind == columnToken {
+			column = quoteIdentifier(column)
+		}
+
+		lookup.columns = append(lookup.columns, column)
+		lookup.values = append(lookup.values, value)
+	}
+
+	if err := e.expect(")"); err != nil {
+		return nil, err
+	}
+
+	if len(lookup.columns) == 0 {
+		return nil, fmt.Errorf("lookup needs at least one column to match, like code = CountryCode")
+	}
+
+	return lookup, nil
+}
+
+// Names between backticks, like `parent id`, are quoted for the database.
+// Both postgres and sqlite quote them with double quotes
+func quoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+func isMissingMode(mode string) bool {
+	for _, candidate := range missingModes {
+		if mode == candidate {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (l *Lookup) selectStatement() string {
+	conditions := []string{}
+
+	for _, column := range l.columns {
+		conditions = append(conditions, column+" = ?")
+	}
+
+	return fmt.Sprintf("SELECT %s FROM %s WHERE %s", l.column, l.table, strings.Join(conditions, " AND "))
+}
+
+func (l *Lookup) insertStatement() string {
+	placeholders := strings.Repeat("?, ", len(l.columns))
+	placeholders, _ = strings.CutSuffix(placeholders, ", ")
+
+	return fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", l.table, strings.Join(l.columns, ", "), placeholders)
+}
+
+func (l *Lookup) generateValue(context InsertContext) (any, error) {
+	values := []any{}
+	keys := []string{}
+
+	for _, insertable := range l.values {
+		value, err := insertable.generateValue(context)
+
+		if err != nil {
+			return nil, err
+		}
+
+		// Nothing is equal to NULL in sql, so there is nothing to look for
+		if value == nil {
+			return nil, nil
+		}
+
+		values = append(values, value)
+		keys = append(keys, strconv.Quote(toString(value)))
+	}
+
+	key := strings.Join(keys, ", ")
+	if value, ok := l.cache[key]; ok {
+		return value, nil
+	}
+
+	value, err := l.query(context, values)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		switch l.missing {
+		case "null":
+			value, err = nil, nil
+		case "insert":
+			value, err = l.insert(context, values)
+		default:
+			err = fmt.Errorf("No row of %s has %s", l.table, l.describe(values))
+		}
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	l.cache[key] = value
+
+	return value, nil
+}
+
+func (l *Lookup) query(context InsertContext, values []any) (any, error) {
+	if context.transaction == nil {
+		return nil, fmt.Errorf("lookup can only be used when inserting")
+	}
+
+	var value any
+	statement := bindParams(l.selectStatement(), context.dialect)
+	err := context.transaction.QueryRow(statement, values...).Scan(&value)
+
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("Could not look up %s.%s: %s", l.table, l.column, err)
+	}
+
+	// Some drivers scan text as bytes
+	if text, ok := value.([]byte); ok {
+		value = string(text)
+	}
+
+	return value, err
+}
+
+// The row is queried again after being inserted, since the looked up
+// column may not be the one LastInsertId returns
+func (l *Lookup) insert(context InsertContext, values []any) (any, error) {
+	statement := bindParams(l.insertStatement(), context.dialect)
+
+	if _, err := context.transaction.Exec(statement, values...); err != nil {
+		return nil, fmt.Errorf("Could not insert %s with %s: %s", l.table, l.describe(values), err)
+	}
+
+	return l.query(context, values)
+}
+
+func (l *Lookup) describe(values []any) string {
+	conditions := []string{}
+
+	for idx, column := range l.columns {
+		conditions = append(conditions, fmt.Sprintf("%s = %s", column, strconv.Quote(toString(values[idx]))))
+	}
+
+	return strings.Join(conditions, " and ")
+}
@@ -0,0 +1,167 @@
+package internal
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestParseLookup(t *testing.T) {
+	tests := []struct {
+		input      string
+		expected   *Lookup
+		shouldFail bool
+	}{
+		{
+			"lookup(countries.id, code = CountryCode)",
+			&Lookup{
+				table:   "countries",
+				column:  "id",
+				columns: []string{"code"},
+				values:  []Insertable{&RegularInsertion{value: "CountryCode"}},
+				missing: "error",
+				cache:   map[string]any{},
+			},
+			false,
+		},
+		{
+			"lookup(categories.id, name = lower(Category), `parent id` = 1, 'insert')",
+			&Lookup{
+				table:   "categories",
+				column:  "id",
+				columns: []string{"name", `"parent id"`},
+				values: []Insertable{
+					&Function{name: "lower", args: []Insertable{&RegularInsertion{value: "Category"}}},
+					&Literal{value: int64(1)},
+				},
+				missing: "insert",
+				cache:   map[string]any{},
+			},
+			false,
+		},
+		{"lookup(countries, code = CountryCode)", nil, true},
+		{"lookup(countries.id)", nil, true},
+		{"lookup(countries.id, code CountryCode)", nil, true},
+		{"lookup(countries.id, code = CountryCode, 'ignore')", nil, true},
+		{"lookup(countries.id, code = CountryCode, 'null', name = Name)", nil, true},
+	}
+
+	for _, tt := range tests {
+		got, err := NewParser(nil).parseFieldValue(tt.input)
+
+		if err != nil {
+			if !tt.shouldFail {
+				t.Errorf("Expected %s to be parsed, but got %s", tt.input, err)
+			}
+
+			continue
+		}
+
+		if tt.shouldFail {
+			t.Errorf("Expected %s to fail, but got %v", tt.input, got)
+		}
+
+		if !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("Expected %v, but got %v", tt.expected, got)
+		}
+	}
+}
+
+func TestLookup(t *testing.T) {
+	database, err := sql.Open("sqlite3", ":memory:")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer database.Close()
+
+	transaction, err := database.Begin()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer transaction.Rollback()
+
+	_, err = transaction.Exec(`
+		CREATE TABLE countries (id INTEGER PRIMARY KEY, code TEXT, name TEXT, "iso code" TEXT);
+		INSERT INTO countries (id, code, name, "iso code") VALUES (1, 'BR', 'Brazil', 'BRA'), (2, 'PT', 'Portugal', 'PRT');
+	`)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		input      string
+		code       any
+		expected   any
+		shouldFail bool
+	}{
+		{"lookup(countries.id, code = Code)", "PT", int64(2), false},
+		{"lookup(countries.name, code = Code)", "BR", "Brazil", false},
+		{"lookup(countries.name, `iso code` = Code)", "PRT", "Portugal", false},
+		{"lookup(countries.id, `iso code` = Code, 'insert')", "ARG", int64(3), false},
+		{"lookup(countries.id, code = Code)", nil, nil, false},
+		{"lookup(countries.id, code = Code)", "AR", nil, true},
+		{"lookup(countries.id, code = Code, 'null')", "AR", nil, false},
+		{"lookup(countries.id, code = Code, 'insert')", "AR", int64(4), false},
+		{"lookup(countries.id, code = Code)", "AR", int64(4), false},
+		{"lookup(cities.id, code = Code)", "BR", nil, true},
+	}
+
+	for _, tt := range tests {
+		lookup, err := NewParser(nil).parseFieldValue(tt.input)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := lookup.generateValue(InsertContext{
+			record:      mapRecord{"Code": tt.code},
+			transaction: transaction,
+		})
+
+		if err != nil {
+			if !tt.shouldFail {
+				t.Errorf("Expected %s to find %v, but got %s", tt.input, tt.code, err)
+			}
+
+			continue
+		}
+
+		if tt.shouldFail {
+			t.Errorf("Expected %s to fail for %v, but got %v", tt.input, tt.code, got)
+		}
+
+		if !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("Expected %v, but got %v", tt.expected, got)
+		}
+	}
+}
+
+func TestLookupStatements(t *testing.T) {
+	parsed, err := NewParser(nil).parseFieldValue("lookup(categories.id, name = Category, `parent id` = 1)")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lookup := parsed.(*Lookup)
+	tests := []struct {
+		got      string
+		expected string
+	}{
+		{lookup.selectStatement(), `SELECT id FROM categories WHERE name = ? AND "parent id" = ?`},
+		{lookup.insertStatement(), `INSERT INTO categories (name, "parent id") VALUES (?, ?)`},
+	}
+
+	for _, tt := range tests {
+		if tt.got != tt.expected {
+			t.Errorf("Expected %v, but got %v", tt.expected, tt.got)
+		}
+	}
+}
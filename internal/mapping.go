@@ -4,17 +4,108 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"regexp"
 
+	"github.com/marcos-brito/sozza/internal/source"
 	"gopkg.in/yaml.v3"
 )
 
-type Mapping = map[string]Item
+type Mapping struct {
+	// Names given to the csv columns. Mostly useful when the file has no header
+	Columns []string
+	// Columns of a fixed-width input file
+	Layout []source.Column
+	// Values inserted as NULL instead, like "" or \N
+	Nulls []string
+	// What references to a skipped insertion become, either null or error
+	Skipped string
+	// Conditions the records of each source must meet to be inserted, by
+	// source name. A single condition is for the default source
+	Filters map[string]string
+	// Seed of the fake data
+	Seed   int64
+	Tables map[string]Item
+}
 
 type Item struct {
-	Insertions  []map[string]string `yaml:"insertions"`
+	// The name of the input file the values come from
+	Source string `yaml:"source"`
+	// Values inserted as NULL for each field. They replace the ones of
+	// the mapping
+	Nulls map[string][]string `yaml:"nulls"`
+	// Types the values of each field are converted to before being inserted
+	Types map[string]FieldType `yaml:"types"`
+	// Fields identifying a row. Insertions with the same values reuse the
+	// row inserted first instead of inserting it again
+	Key []string `yaml:"key"`
+	// How rows violating a unique constraint are handled
+	Conflict *Conflict `yaml:"conflict"`
+	// Column read as the id of the rows other tables reference. rowid for
+	// sqlite and id for the other databases by default
+	Id string `yaml:"id"`
+	// List the insertions are repeated for, once per element
+	Explode *Explode `yaml:"explode"`
+	// Fields of each row inserted. The when and alias keys are not fields,
+	// but the condition for the insertion to happen and the name it is
+	// referenced by
+	Insertions []map[string]string `yaml:"insertions"`
+}
+
+// Every top level key is a table, except for the ones used as options. Since
+// they share the same keys, a table named like an option is read as the
+// option, and the error says so
+func (m *Mapping) UnmarshalYAML(node *yaml.Node) error {
+	entries := map[string]yaml.Node{}
+
+	if err := node.Decode(&entries); err != nil {
+		return err
+	}
+
+	m.Tables = map[string]Item{}
+	for key, value := range entries {
+		var err error
+
+		switch key {
+		case "columns":
+			err = value.Decode(&m.Columns)
+		case "layout":
+			err = value.Decode(&m.Layout)
+		case "nulls":
+			err = value.Decode(&m.Nulls)
+		case "seed":
+			err = value.Decode(&m.Seed)
+		case "filter":
+			if value.Kind == yaml.ScalarNode {
+				m.Filters = map[string]string{"": value.Value}
+			} else {
+				err = value.Decode(&m.Filters)
+			}
+		case "skipped":
+			// Decoding null into a string would leave it empty
+			if value.Kind != yaml.ScalarNode || (value.Value != "null" && value.Value != "error") {
+				err = fmt.Errorf("it must be null or error")
+			}
+
+			m.Skipped = value.Value
+		default:
+			item := Item{}
+
+			if err := value.Decode(&item); err != nil {
+				return err
+			}
+
+			m.Tables[key] = item
+		}
+
+		if err != nil {
+			return fmt.Errorf("Could not read %s, which is an option of the mapping and can't name a table: %s", key, err)
+		}
+	}
+
+	return nil
 }
 
-func ReadMappingFromFile(path string) (*Mapping, error) {
+func ReadMappingFromFile(path string, params map[string]string) (*Mapping, error) {
 	file, err := os.Open(path)
 
 	if err != nil {
@@ -27,7 +118,7 @@ func ReadMappingFromFile(path string) (*Mapping, error) {
 		return nil, err
 	}
 
-	mapping, err := ReadMapping(content)
+	mapping, err := ReadMapping(content, params)
 	if err != nil {
 		return nil, err
 	}
@@ -35,9 +126,26 @@ func ReadMappingFromFile(path string) (*Mapping, error) {
 	return mapping, nil
 }
 
-func ReadMapping(content []byte) (*Mapping, error) {
+// Variables like ${TENANT} in the values are replaced by the params or
+// else by the environment before the mapping is decoded
+func ReadMapping(content []byte, params map[string]string) (*Mapping, error) {
+	document := yaml.Node{}
+	err := yaml.Unmarshal(content, &document)
+
+	if err != nil {
+		return nil, fmt.Errorf("Could not unmarshal the mapping: %s", err)
+	}
+
+	if err := interpolateNode(&document, params); err != nil {
+		return nil, err
+	}
+
 	m := &Mapping{}
-	err := yaml.Unmarshal(content, m)
+	if len(document.Content) == 0 {
+		return m, nil
+	}
+
+	err = document.Decode(m)
 
 	if err != nil {
 		return nil, fmt.Errorf("Could not unmarshal the mapping: %s", err)
@@ -45,3 +153,86 @@ func ReadMapping(content []byte) (*Mapping, error) {
 
 	return m, nil
 }
+
+// Matches ${NAME}. Variables written as $${NAME} are kept as ${NAME}
+var variablePattern = regexp.MustCompile(`\$(\$?)\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// Only values are interpolated, keys are left as they are
+func interpolateNode(node *yaml.Node, params map[string]string) error {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		value, err := interpolate(node.Value, params)
+
+		if err != nil {
+			return err
+		}
+
+		// Plain values are resolved again, so numbers stay numbers
+		if value != node.Value && node.Style == 0 {
+			node.Tag = ""
+		}
+
+		node.Value = value
+	case yaml.MappingNode:
+		for idx := 1; idx < len(node.Content); idx += 2 {
+			if err := interpolateNode(node.Content[idx], params); err != nil {
+				return err
+			}
+		}
+	default:
+		for _, child := range node.Content {
+			if err := interpolateNode(child, params); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func interpolate(text string, params map[string]string) (string, error) {
+	var missing error
+
+	interpolated := variablePattern.ReplaceAllStringFunc(text, func(match string) string {
+		groups := variablePattern.FindStringSubmatch(match)
+		escaped, name := groups[1], groups[2]
+
+		if escaped != "" {
+			return match[1:]
+		}
+
+		if value, ok := params[name]; ok {
+			return value
+		}
+
+		if value, ok := os.LookupEnv(name); ok {
+			return value
+		}
+
+		if missing == nil {
+			missing = fmt.Errorf("Undefined variable %s in %s", name, text)
+		}
+
+		return match
+	})
+
+	return interpolated, missing
+}
+
+// A layout may be kept in its own file instead of the mapping
+func ReadLayoutFromFile(path string) ([]source.Column, error) {
+	content, err := os.ReadFile(path)
+
+	if err != nil {
+		return nil, fmt.Errorf("Could not read the layout file: %s", err)
+	}
+
+	layout := []source.Column{}
+	err = yaml.Unmarshal(content, &layout)
+
+	if err != nil {
+		return nil, fmt.Errorf("Could not unmarshal the layout: %s", err)
+	}
+
+	return layout, nil
+}
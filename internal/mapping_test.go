@@ -2,7 +2,10 @@ package internal
 
 import (
 	"reflect"
+	"strings"
 	"testing"
+
+	"github.com/marcos-brito/sozza/internal/source"
 )
 
 func TestParsingMappingFile(t *testing.T) {
@@ -21,17 +24,200 @@ func TestParsingMappingFile(t *testing.T) {
                       field3: csv3
             `,
 			&Mapping{
-				"table1": Item{
-					Insertions: []map[string]string{
-						{
-							"field1": "csv1",
-							"field2": "csv2",
-							"field3": "csv3",
+				Tables: map[string]Item{
+					"table1": {
+						Insertions: []map[string]string{
+							{
+								"field1": "csv1",
+								"field2": "csv2",
+								"field3": "csv3",
+							},
+							{
+								"field1": "csv1",
+								"field2": "csv2",
+								"field3": "csv3",
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			`
+columns: [Email, UserName]
+table1:
+    insertions:
+        - field1: Email
+          field2: $2
+`,
+			&Mapping{
+				Columns: []string{"Email", "UserName"},
+				Tables: map[string]Item{
+					"table1": {
+						Insertions: []map[string]string{
+							{
+								"field1": "Email",
+								"field2": "$2",
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			`
+layout:
+    - name: Code
+      start: 1
+      length: 4
+table1:
+    insertions:
+        - field1: Code
+`,
+			&Mapping{
+				Layout: []source.Column{
+					{Name: "Code", Start: 1, Length: 4},
+				},
+				Tables: map[string]Item{
+					"table1": {
+						Insertions: []map[string]string{
+							{
+								"field1": "Code",
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			`
+nulls: ["", "\\N"]
+table1:
+    nulls:
+        field1: ["N/A"]
+    insertions:
+        - field1: csv1
+`,
+			&Mapping{
+				Nulls: []string{"", "\\N"},
+				Tables: map[string]Item{
+					"table1": {
+						Nulls: map[string][]string{
+							"field1": {"N/A"},
 						},
-						{
-							"field1": "csv1",
-							"field2": "csv2",
-							"field3": "csv3",
+						Insertions: []map[string]string{
+							{
+								"field1": "csv1",
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			`
+table1:
+    types:
+        field1: int
+        field2:
+            type: timestamp
+            layouts: ["02/01/2006", epoch]
+            timezone: America/Sao_Paulo
+    insertions:
+        - field1: csv1
+          field2: csv2
+`,
+			&Mapping{
+				Tables: map[string]Item{
+					"table1": {
+						Types: map[string]FieldType{
+							"field1": {Name: "int"},
+							"field2": {
+								Name:     "timestamp",
+								Layouts:  []string{"02/01/2006", "epoch"},
+								Timezone: "America/Sao_Paulo",
+							},
+						},
+						Insertions: []map[string]string{
+							{
+								"field1": "csv1",
+								"field2": "csv2",
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			`
+skipped: null
+seed: 42
+filter: country == "BR" && age > 18
+table1:
+    key: [field1]
+    explode:
+        value: csv2
+        separator: ";"
+    conflict:
+        on: [field1]
+        do: update
+        update: [field1]
+    id: code
+    insertions:
+        - field1: csv1
+          when: csv1 != ''
+          alias: main
+`,
+			&Mapping{
+				Skipped: "null",
+				Seed:    42,
+				Filters: map[string]string{"": `country == "BR" && age > 18`},
+				Tables: map[string]Item{
+					"table1": {
+						Key: []string{"field1"},
+						Explode: &Explode{
+							Value:     "csv2",
+							Separator: ";",
+						},
+						Conflict: &Conflict{
+							On:     []string{"field1"},
+							Do:     "update",
+							Update: []string{"field1"},
+						},
+						Id: "code",
+						Insertions: []map[string]string{
+							{
+								"field1": "csv1",
+								"when":   "csv1 != ''",
+								"alias":  "main",
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			`
+filter:
+    users: country == "BR"
+    houses: size > 2
+table1:
+    source: users
+    insertions:
+        - field1: csv1
+`,
+			&Mapping{
+				Filters: map[string]string{
+					"users":  `country == "BR"`,
+					"houses": "size > 2",
+				},
+				Tables: map[string]Item{
+					"table1": {
+						Source: "users",
+						Insertions: []map[string]string{
+							{
+								"field1": "csv1",
+							},
 						},
 					},
 				},
@@ -40,7 +226,7 @@ func TestParsingMappingFile(t *testing.T) {
 	}
 
 	for _, tt := range tests {
-		got, err := ReadMapping([]byte(tt.input))
+		got, err := ReadMapping([]byte(tt.input), nil)
 
 		if err != nil {
 			t.Error(err)
@@ -52,3 +238,92 @@ func TestParsingMappingFile(t *testing.T) {
 	}
 
 }
+
+func TestReservedMappingKeys(t *testing.T) {
+	tests := []string{
+		"columns:\n  insertions:\n    - name: Name",
+		"seed:\n  insertions:\n    - name: Name",
+		"filter:\n  insertions:\n    - name: Name",
+		"skipped:\n  insertions:\n    - name: Name",
+	}
+
+	for _, input := range tests {
+		_, err := ReadMapping([]byte(input), nil)
+		key, _, _ := strings.Cut(input, ":")
+
+		if err == nil || !strings.Contains(err.Error(), key+", which is an option of the mapping") {
+			t.Errorf("Expected %s to fail naming the reserved key, but got %v", input, err)
+		}
+	}
+}
+
+func TestInterpolateMapping(t *testing.T) {
+	t.Setenv("SOZZA_TENANT", "7")
+	t.Setenv("SOZZA_BATCH", "from env")
+
+	tests := []struct {
+		input      string
+		params     map[string]string
+		expected   *Mapping
+		shouldFail bool
+	}{
+		{
+			`
+seed: ${SEED}
+table1:
+    insertions:
+        - tenant: ${SOZZA_TENANT}
+          batch: "'${SOZZA_BATCH}'"
+          price: $${PRICE}
+          ${SOZZA_TENANT}: csv1
+`,
+			map[string]string{"SEED": "3", "SOZZA_BATCH": "from params"},
+			&Mapping{
+				Seed: 3,
+				Tables: map[string]Item{
+					"table1": {
+						Insertions: []map[string]string{
+							{
+								"tenant":          "7",
+								"batch":           "'from params'",
+								"price":           "${PRICE}",
+								"${SOZZA_TENANT}": "csv1",
+							},
+						},
+					},
+				},
+			},
+			false,
+		},
+		{
+			`
+table1:
+    insertions:
+        - tenant: ${SOZZA_UNDEFINED}
+`,
+			nil,
+			nil,
+			true,
+		},
+	}
+
+	for _, tt := range tests {
+		got, err := ReadMapping([]byte(tt.input), tt.params)
+
+		if err != nil {
+			if !tt.shouldFail {
+				t.Error(err)
+			}
+
+			continue
+		}
+
+		if tt.shouldFail {
+			t.Errorf("Expected %s to fail, but got %v", tt.input, got)
+		}
+
+		if !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("Expected %v, but got %v", tt.expected, got)
+		}
+	}
+}
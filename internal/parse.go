@@ -1,9 +1,12 @@
 package internal
 
 import (
+	"cmp"
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"unicode"
@@ -11,8 +14,25 @@ import (
 	log "github.com/sirupsen/logrus"
 )
 
+// Keys of an insertion holding its condition and the name it is referenced
+// by instead of fields
+const (
+	whenKey  = "when"
+	aliasKey = "alias"
+)
+
+var aliasPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
 type Parser struct {
-	mapping Mapping
+	mapping *Mapping
+	// Where the value being parsed is, like user:0:email, and how many
+	// fakes it had so far. Used to seed fakes
+	location string
+	fakes    int
+	// Table and column of the field being parsed, like user.id. The
+	// insertions of a table share the sequence of each column
+	column    string
+	sequences map[string]*Generator
 }
 
 type FormatedInput struct {
@@ -22,45 +42,275 @@ type FormatedInput struct {
 
 type TableReference struct {
 	referenceTable string
-	insertion      int
+	// Alias of the insertion referenced, as in __table.alias__
+	alias     string
+	insertion int
+	// Column of the row referenced, as in __table__.column. The id if empty
+	column string
 }
 
 type RegularInsertion struct {
 	value string
 }
 
-func NewParser(mapping Mapping) *Parser {
+// A constant value inserted as it is, like 'active', 42, true or null
+type Literal struct {
+	value any
+}
+
+func NewParser(mapping *Mapping) *Parser {
 	return &Parser{
-		mapping: mapping,
+		mapping:   mapping,
+		sequences: map[string]*Generator{},
 	}
 }
 
 func (p *Parser) parse() ([]Table, error) {
 	tables := []Table{}
 
-	for tableName, item := range p.mapping {
+	for tableName, item := range p.mapping.Tables {
+		if err := validateReserved(tableName, item); err != nil {
+			return nil, err
+		}
+
+		converters := map[string]converter{}
+		for field, fieldType := range item.Types {
+			convert, err := newConverter(fieldType)
+
+			if err != nil {
+				return nil, fmt.Errorf("Error parsing the type of %s:%s: %s", tableName, field, err)
+			}
+
+			converters[field] = convert
+		}
+
+		var explode *explosion
+		if item.Explode != nil {
+			p.location, p.fakes = tableName+":explode", 0
+
+			var err error
+			if explode, err = p.parseExplode(item.Explode); err != nil {
+				return nil, fmt.Errorf("Error parsing the explode of %s: %s", tableName, err)
+			}
+		}
+
 		for idx, insertion := range item.Insertions {
 			fields := map[string]Insertable{}
+			nulls := map[string][]string{}
+			var condition Insertable
+			alias, hasAlias := insertion[aliasKey]
+
+			if hasAlias && !aliasPattern.MatchString(alias) {
+				return nil, fmt.Errorf("The alias of %s:%d must be a name like main, but got %s", tableName, idx, alias)
+			}
 
 			for field, value := range insertion {
+				if field == aliasKey {
+					continue
+				}
+
+				if field == whenKey {
+					p.location, p.fakes = fmt.Sprintf("%s:%d:%s", tableName, idx, whenKey), 0
+
+					var err error
+					if condition, err = p.parseCondition(value); err != nil {
+						return nil, fmt.Errorf("Error parsing the condition of %s:%d: %s", tableName, idx, err)
+					}
+
+					continue
+				}
+
 				log.Debugf("Parsing %s:%d:%s", tableName, idx, field)
+				p.location, p.fakes = fmt.Sprintf("%s:%d:%s", tableName, idx, field), 0
+				p.column = tableName + "." + field
 				insertable, err := p.parseFieldValue(value)
+				p.column = ""
 
 				if err != nil {
 					return nil, fmt.Errorf("Error parsing %s:%d:%s: %s", tableName, idx, field, err)
 				}
 
 				fields[field] = insertable
+				nulls[field] = p.mapping.Nulls
+
+				if sentinels, ok := item.Nulls[field]; ok {
+					nulls[field] = sentinels
+				}
+			}
+
+			for _, field := range item.Key {
+				if _, ok := fields[field]; !ok {
+					return nil, fmt.Errorf("The key of %s has %s, but %s:%d does not insert it", tableName, field, tableName, idx)
+				}
 			}
 
-			tables = append(tables, *newTable(tableName, fields))
+			if item.Conflict != nil {
+				if err := item.Conflict.validate(fields); err != nil {
+					return nil, fmt.Errorf("Error parsing the conflict of %s:%d: %s", tableName, idx, err)
+				}
+			}
+
+			table := newTable(tableName, item.Source, fields)
+			table.nulls = nulls
+			table.converters = converters
+			table.condition = condition
+			table.key = item.Key
+			table.conflict = item.Conflict
+			table.explode = explode
+			table.alias = alias
+			table.id = item.Id
+			table.generatedId = generatedId(fields, cmp.Or(item.Id, "id"))
+			tables = append(tables, *table)
 		}
 	}
 
+	if err := validateAliases(tables); err != nil {
+		return nil, err
+	}
+
+	findReferenced(tables)
+
 	return tables, nil
 }
 
+// The when and alias keys are not fields, so the options of the table can't
+// name them as columns either
+func validateReserved(tableName string, item Item) error {
+	columns := slices.Clone(item.Key)
+
+	for column := range item.Types {
+		columns = append(columns, column)
+	}
+
+	for column := range item.Nulls {
+		columns = append(columns, column)
+	}
+
+	if item.Conflict != nil {
+		columns = append(append(columns, item.Conflict.On...), item.Conflict.Update...)
+	}
+
+	for _, column := range columns {
+		if column == whenKey || column == aliasKey {
+			return fmt.Errorf("%s is reserved in insertions and can't be a column of %s", column, tableName)
+		}
+	}
+
+	return nil
+}
+
+// The id column when it is generated, so references get its value with no
+// need to ask the database. Fields computed from a generator, like
+// seq() | pad(8, '0'), count as generated too. Other generated fields, like
+// a code next to a database id, are only values
+func generatedId(fields map[string]Insertable, column string) string {
+	if insertable, ok := fields[column]; ok && isGenerated(insertable) {
+		return column
+	}
+
+	return ""
+}
+
+func isGenerated(insertable Insertable) bool {
+	if _, ok := insertable.(*Generator); ok {
+		return true
+	}
+
+	return slices.ContainsFunc(childrenOf(insertable), isGenerated)
+}
+
+// Marks the tables other tables reference, so their ids are read back when
+// they are inserted. Columns referenced with __table__.column are read back
+// too
+func findReferenced(tables []Table) {
+	for _, table := range tables {
+		for _, reference := range table.findReferences() {
+			for idx := range tables {
+				referenced := &tables[idx]
+
+				if referenced.name != reference.referenceTable || (reference.alias != "" && referenced.alias != reference.alias) {
+					continue
+				}
+
+				referenced.referenced = true
+
+				if reference.column != "" && !slices.Contains(referenced.returning, reference.column) {
+					referenced.returning = append(referenced.returning, reference.column)
+				}
+			}
+		}
+	}
+}
+
+// Aliases must be unique within a table, and references to them must name
+// one of its insertions. A dot in a name that is not a mapped table is part
+// of the table name instead, as in __schema.table__
+func validateAliases(tables []Table) error {
+	aliases := map[string]bool{}
+	names := map[string]bool{}
+
+	for _, table := range tables {
+		names[table.name] = true
+
+		if table.alias == "" {
+			continue
+		}
+
+		if aliases[table.name+"."+table.alias] {
+			return fmt.Errorf("%s has more than one insertion with the %s alias", table.name, table.alias)
+		}
+
+		aliases[table.name+"."+table.alias] = true
+	}
+
+	for _, table := range tables {
+		for _, reference := range table.findReferences() {
+			if reference.alias == "" || aliases[reference.referenceTable+"."+reference.alias] {
+				continue
+			}
+
+			if !names[reference.referenceTable] {
+				reference.referenceTable += "." + reference.alias
+				reference.alias = ""
+				continue
+			}
+
+			return fmt.Errorf(
+				"%s references __%s.%s__, but %s has no insertion with that alias",
+				table.name,
+				reference.referenceTable,
+				reference.alias,
+				reference.referenceTable,
+			)
+		}
+	}
+
+	return nil
+}
+
 func (p *Parser) parseFieldValue(value string) (Insertable, error) {
+	if strings.Contains(value, "{{") {
+		return p.parseTemplate(value)
+	}
+
+	if stages := splitTopLevel(value, '|'); len(stages) > 1 {
+		return p.parsePipeline(stages)
+	}
+
+	// Columns with spaces or pipes in their names are written between
+	// backticks, as in function arguments
+	if len(value) >= 2 && value[0] == '`' && strings.IndexByte(value[1:], '`') == len(value)-2 {
+		return p.parseRegularInsertion(value[1 : len(value)-1])
+	}
+
+	if isFunctionCall(value) {
+		return p.parseExpression(value)
+	}
+
+	if isQuoted(value) || isNumber(value) || isBoolean(value) || value == "null" {
+		return p.parseLiteral(value)
+	}
+
 	if strings.HasPrefix(value, "__") {
 		return p.parseTableReference(value)
 	}
@@ -79,6 +329,15 @@ func (p *Parser) parseTableReference(value string) (Insertable, error) {
 		values = append(values, strings.TrimSpace(v))
 	}
 
+	column := ""
+	if end := strings.LastIndex(values[0], "__."); end > 1 {
+		values[0], column = values[0][:end+2], values[0][end+3:]
+
+		if column == "" {
+			return nil, fmt.Errorf("Missing the column after %s.", values[0])
+		}
+	}
+
 	if !(strings.HasPrefix(values[0], "__") && strings.HasSuffix(values[0], "__")) &&
 		len(values) == 1 {
 		return p.parseRegularInsertion(value)
@@ -92,25 +351,30 @@ func (p *Parser) parseTableReference(value string) (Insertable, error) {
 			)
 		}
 
-		return &TableReference{
-			referenceTable: values[0][2 : len(values[0])-2],
-			insertion:      0,
-		}, nil
+		return newTableReference(values[0][2:len(values[0])-2], 0, column), nil
 	}
 
 	if isNumeric(values[1]) && len(values) <= 2 {
 		// We already know it is a valid number. No need to handle the error
 		insertion, _ := strconv.Atoi(values[1])
 
-		return &TableReference{
-			referenceTable: values[0][2 : len(values[0])-2],
-			insertion:      insertion,
-		}, nil
+		return newTableReference(values[0][2:len(values[0])-2], insertion, column), nil
 	}
 
 	return nil, fmt.Errorf("Unexpected text after %s. It should be a single number", values[0])
 }
 
+// Anything after the last dot of the name is taken as the alias of an
+// insertion. Names of tables with dots are told apart once all the aliases
+// are known
+func newTableReference(name string, insertion int, column string) *TableReference {
+	if dot := strings.LastIndex(name, "."); dot != -1 {
+		return &TableReference{referenceTable: name[:dot], alias: name[dot+1:], insertion: insertion, column: column}
+	}
+
+	return &TableReference{referenceTable: name, insertion: insertion, column: column}
+}
+
 func (p *Parser) parseFormatedInput(value string) (Insertable, error) {
 	values := []string{}
 
@@ -140,6 +404,115 @@ func (p *Parser) parseRegularInsertion(value string) (Insertable, error) {
 	}, nil
 }
 
+// Strings must be quoted with ' or ". Remember that yaml removes the quotes
+// around its own strings, so '"active"' is needed instead of "active"
+func (p *Parser) parseLiteral(value string) (Insertable, error) {
+	switch {
+	case isQuoted(value):
+		return &Literal{value: value[1 : len(value)-1]}, nil
+	case isBoolean(value):
+		return &Literal{value: value == "true"}, nil
+	case value == "null":
+		return &Literal{value: nil}, nil
+	case isNumber(value):
+		if integer, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return &Literal{value: integer}, nil
+		}
+
+		float, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s is not a valid number: %s", value, err)
+		}
+
+		return &Literal{value: float}, nil
+	default:
+		return nil, fmt.Errorf("%s is not a literal", value)
+	}
+}
+
+func isQuoted(candidate string) bool {
+	if len(candidate) < 2 {
+		return false
+	}
+
+	first, last := candidate[0], candidate[len(candidate)-1]
+
+	return (first == '\'' || first == '"') && first == last
+}
+
+func isBoolean(candidate string) bool {
+	return candidate == "true" || candidate == "false"
+}
+
+// Unlike isNumeric, it accepts signs and decimals
+func isNumber(candidate string) bool {
+	integer, decimals, _ := strings.Cut(strings.TrimPrefix(candidate, "-"), ".")
+
+	return integer != "" && isNumeric(integer) && isNumeric(decimals)
+}
+
+// The value of each stage is passed as the first argument of the next one.
+// The first stage can be any value, the others must be function calls
+func (p *Parser) parsePipeline(stages []string) (Insertable, error) {
+	value, err := p.parseFieldValue(strings.TrimSpace(stages[0]))
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, stage := range stages[1:] {
+		tokens, err := tokenize(stage)
+
+		if err != nil {
+			return nil, err
+		}
+
+		expression := &expressionParser{parser: p, tokens: tokens}
+		value, err = expression.parseCall(value)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if !expression.done() {
+			return nil, fmt.Errorf("Unexpected %s after %s", expression.peek().text, strings.TrimSpace(stage))
+		}
+	}
+
+	return value, nil
+}
+
+func (p *Parser) parseExpression(value string) (Insertable, error) {
+	tokens, err := tokenize(value)
+
+	if err != nil {
+		return nil, err
+	}
+
+	expression := &expressionParser{parser: p, tokens: tokens}
+	insertable, err := expression.parseOperand()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !expression.done() {
+		return nil, fmt.Errorf("Unexpected %s in %s", expression.peek().text, value)
+	}
+
+	return insertable, nil
+}
+
+func (p *Parser) parseTemplate(value string) (Insertable, error) {
+	return newTemplate(value)
+}
+
+func isFunctionCall(candidate string) bool {
+	name, _, found := strings.Cut(candidate, "(")
+
+	return found && (isBuiltin(name) || isGenerator(name) || name == lookupName || name == fakeName) && strings.HasSuffix(candidate, ")")
+}
+
 func isValidPath(candidate string) bool {
 	_, err := os.Stat(filepath.Clean(candidate))
 
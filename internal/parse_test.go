@@ -5,6 +5,7 @@ import (
 	"os"
 	"path"
 	"reflect"
+	"slices"
 	"strings"
 	"testing"
 )
@@ -62,6 +63,26 @@ func TestParseFieldValue(t *testing.T) {
 			&RegularInsertion{value: "_table__"},
 			false,
 		},
+		{
+			"'active'",
+			&Literal{value: "active"},
+			false,
+		},
+		{
+			"42",
+			&Literal{value: int64(42)},
+			false,
+		},
+		{
+			"false",
+			&Literal{value: false},
+			false,
+		},
+		{
+			"Email",
+			&RegularInsertion{value: "Email"},
+			false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -116,6 +137,143 @@ func TestParseRegularInsertion(t *testing.T) {
 	}
 
 }
+func TestParsePipeline(t *testing.T) {
+	tests := []struct {
+		value      string
+		expected   Insertable
+		shouldFail bool
+	}{
+		{
+			"Email | trim | lower",
+			&Function{name: "lower", args: []Insertable{
+				&Function{name: "trim", args: []Insertable{&RegularInsertion{value: "Email"}}},
+			}},
+			false,
+		},
+		{
+			"First Name | replace(' ', '_')",
+			&Function{name: "replace", args: []Insertable{
+				&RegularInsertion{value: "First Name"},
+				&Literal{value: " "},
+				&Literal{value: "_"},
+			}},
+			false,
+		},
+		{
+			"concat(FirstName, ' ', `Last Name`)",
+			&Function{name: "concat", args: []Insertable{
+				&RegularInsertion{value: "FirstName"},
+				&Literal{value: " "},
+				&RegularInsertion{value: "Last Name"},
+			}},
+			false,
+		},
+		{
+			"coalesce(Nickname, upper(Name), null) | pad(10, '.', 'right')",
+			&Function{name: "pad", args: []Insertable{
+				&Function{name: "coalesce", args: []Insertable{
+					&RegularInsertion{value: "Nickname"},
+					&Function{name: "upper", args: []Insertable{&RegularInsertion{value: "Name"}}},
+					&Literal{value: nil},
+				}},
+				&Literal{value: int64(10)},
+				&Literal{value: "."},
+				&Literal{value: "right"},
+			}},
+			false,
+		},
+		{
+			"__house__ | concat('-', $2)",
+			&Function{name: "concat", args: []Insertable{
+				&TableReference{referenceTable: "house", insertion: 0},
+				&Literal{value: "-"},
+				&RegularInsertion{value: "$2"},
+			}},
+			false,
+		},
+		{
+			"regex_extract(Phone, Pattern)",
+			&Function{name: "regex_extract", args: []Insertable{
+				&RegularInsertion{value: "Phone"},
+				&RegularInsertion{value: "Pattern"},
+			}},
+			false,
+		},
+		{
+			"concat(Name, '|', `A|B`) | lower",
+			&Function{name: "lower", args: []Insertable{
+				&Function{name: "concat", args: []Insertable{
+					&RegularInsertion{value: "Name"},
+					&Literal{value: "|"},
+					&RegularInsertion{value: "A|B"},
+				}},
+			}},
+			false,
+		},
+		{"`A|B`", &RegularInsertion{value: "A|B"}, false},
+		{"`A|B` | lower", &Function{name: "lower", args: []Insertable{&RegularInsertion{value: "A|B"}}}, false},
+		{"'red|blue'", &Literal{value: "red|blue"}, false},
+		{"regex_extract(Phone, '(a')", nil, true},
+		{"Email | frobnicate", nil, true},
+		{"Email | lower(", nil, true},
+		{"Email | replace(' ' '_')", nil, true},
+		{"Email | lower upper", nil, true},
+		{"Email | upper('a', 'b')", nil, true},
+	}
+
+	for _, tt := range tests {
+		parser := NewParser(nil)
+
+		got, err := parser.parseFieldValue(tt.value)
+
+		if tt.shouldFail && err == nil {
+			t.Errorf("Expected \"%s\", to fail but got %v", tt.value, got)
+			continue
+		}
+
+		if !reflect.DeepEqual(tt.expected, got) && !tt.shouldFail {
+			t.Errorf("Expected %v, but got %v: %s", tt.expected, got, tt.value)
+		}
+	}
+}
+
+func TestParseLiteral(t *testing.T) {
+	tests := []struct {
+		value      string
+		expected   Insertable
+		shouldFail bool
+	}{
+		{"'active'", &Literal{value: "active"}, false},
+		{`"import-2026"`, &Literal{value: "import-2026"}, false},
+		{"''", &Literal{value: ""}, false},
+		{"'it has spaces'", &Literal{value: "it has spaces"}, false},
+		{"12", &Literal{value: int64(12)}, false},
+		{"-7", &Literal{value: int64(-7)}, false},
+		{"3.14", &Literal{value: 3.14}, false},
+		{"-0.5", &Literal{value: -0.5}, false},
+		{"true", &Literal{value: true}, false},
+		{"false", &Literal{value: false}, false},
+		{"null", &Literal{value: nil}, false},
+		{"'unbalanced\"", nil, true},
+		{"Email", nil, true},
+	}
+
+	for _, tt := range tests {
+		parser := NewParser(nil)
+
+		got, err := parser.parseLiteral(tt.value)
+
+		if tt.shouldFail && err == nil {
+			t.Errorf("Expected \"%s\", to fail but got %v", tt.value, got)
+			continue
+		}
+
+		if !reflect.DeepEqual(tt.expected, got) && !tt.shouldFail {
+			t.Errorf("Expected %v, but got %v: %s", tt.expected, got, tt.value)
+		}
+	}
+}
+
 func TestParseFormattedInput(t *testing.T) {
 	binarys := []string{
 		getRandomBinaryPath(),
@@ -240,6 +398,48 @@ func TestParseTableReference(t *testing.T) {
 			},
 			false,
 		},
+		{
+			"__table.main__",
+			&TableReference{
+				referenceTable: "table",
+				alias:          "main",
+				insertion:      0,
+			},
+			false,
+		},
+		{
+			"__table.main__, 2",
+			&TableReference{
+				referenceTable: "table",
+				alias:          "main",
+				insertion:      2,
+			},
+			false,
+		},
+		{
+			"__table__.slug",
+			&TableReference{
+				referenceTable: "table",
+				insertion:      0,
+				column:         "slug",
+			},
+			false,
+		},
+		{
+			"__table.main__.slug, 1",
+			&TableReference{
+				referenceTable: "table",
+				alias:          "main",
+				insertion:      1,
+				column:         "slug",
+			},
+			false,
+		},
+		{
+			"__table__.",
+			nil,
+			true,
+		},
 		{
 			"__table__, random",
 			nil,
@@ -280,6 +480,112 @@ func getRandomBinaryPath() string {
 	return path.Join(dirPath, bin.Name())
 }
 
+func TestValidateAliases(t *testing.T) {
+	house := func(alias string) Table {
+		table := newTable("house", "", map[string]Insertable{"color": &Literal{value: "red"}})
+		table.alias = alias
+
+		return *table
+	}
+
+	user := func(reference string) Table {
+		insertable, _ := NewParser(nil).parseTableReference(reference)
+
+		return *newTable("user", "", map[string]Insertable{"house_id": insertable})
+	}
+
+	tests := []struct {
+		tables     []Table
+		shouldFail bool
+	}{
+		{[]Table{house("main"), house("summer"), user("__house.summer__")}, false},
+		{[]Table{house("main"), house(""), user("__house__, 1")}, false},
+		{[]Table{house("main"), user("__house.winter__")}, true},
+		{[]Table{house("main"), house("main")}, true},
+		{[]Table{house("main"), user("__public.house__")}, false},
+	}
+
+	for _, tt := range tests {
+		err := validateAliases(tt.tables)
+
+		if (err != nil) != tt.shouldFail {
+			t.Errorf("Expected failure to be %v, but got %v", tt.shouldFail, err)
+		}
+	}
+
+	schema := user("__public.house__")
+	if err := validateAliases([]Table{house("main"), schema}); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := &TableReference{referenceTable: "public.house"}
+	if got := schema.fields["house_id"]; !reflect.DeepEqual(expected, got) {
+		t.Errorf("Expected %v, but got %v", expected, got)
+	}
+}
+
+func TestParseReservedKeys(t *testing.T) {
+	tests := []struct {
+		input      string
+		shouldFail bool
+	}{
+		{"house:\n  insertions:\n    - color: Color\n      alias: main", false},
+		{"house:\n  insertions:\n    - color: Color\n      alias: Alias Column", true},
+		{"house:\n  insertions:\n    - color: Color\n      when: HouseColor !", true},
+		{"house:\n  types:\n    alias: int\n  insertions:\n    - color: Color", true},
+		{"house:\n  key: [when]\n  insertions:\n    - color: Color", true},
+	}
+
+	for _, tt := range tests {
+		mapping, err := ReadMapping([]byte(tt.input), nil)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = NewParser(mapping).parse()
+
+		if (err != nil) != tt.shouldFail {
+			t.Errorf("Expected %s to fail: %v, but got %v", tt.input, tt.shouldFail, err)
+		}
+	}
+}
+
+func TestFindReferenced(t *testing.T) {
+	parser := NewParser(nil)
+	slug, _ := parser.parseFieldValue("lower(__house__.slug)")
+	code, _ := parser.parseFieldValue("__house.main__.code")
+	id, _ := parser.parseFieldValue("__house__")
+
+	main := newTable("house", "", map[string]Insertable{})
+	main.alias = "main"
+
+	tables := []Table{
+		*main,
+		*newTable("house", "", map[string]Insertable{}),
+		*newTable("user", "", map[string]Insertable{"slug": slug, "code": code, "house_id": id}),
+		*newTable("tag", "", map[string]Insertable{}),
+	}
+
+	findReferenced(tables)
+
+	expected := [][]string{{"slug", "code"}, {"slug"}, nil, nil}
+	referenced := []bool{true, true, false, false}
+	for idx, table := range tables {
+		got := table.returning
+		slices.Sort(got)
+		slices.Sort(expected[idx])
+
+		if !reflect.DeepEqual(expected[idx], got) {
+			t.Errorf("Expected %v, but got %v", expected[idx], got)
+		}
+
+		if table.referenced != referenced[idx] {
+			t.Errorf("Expected %s to be referenced: %v, but got %v", table.name, referenced[idx], table.referenced)
+		}
+	}
+}
+
 func TestIsValidPath(t *testing.T) {
 	tests := []struct {
 		candidate string
@@ -355,3 +661,29 @@ func TestIsNumeric(t *testing.T) {
 		}
 	}
 }
+
+func TestIsNumber(t *testing.T) {
+	tests := []struct {
+		candidate string
+		expected  bool
+	}{
+		{"123", true},
+		{"-123", true},
+		{"1.5", true},
+		{"-0.25", true},
+		{"-", false},
+		{"", false},
+		{".5", false},
+		{"1.2.3", false},
+		{"12a", false},
+		{"--1", false},
+	}
+
+	for _, tt := range tests {
+		got := isNumber(tt.candidate)
+
+		if got != tt.expected {
+			t.Errorf("Expected %t, but got %t: %s", tt.expected, got, tt.candidate)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package source
+
+import (
+	"fmt"
+	"io"
+)
+
+// Gives a number of records without any columns, for mappings that only use
+// literals, generators and fake data
+type Blank struct {
+	remaining int
+}
+
+type BlankRecord struct{}
+
+func NewBlank(count int) *Blank {
+	return &Blank{remaining: count}
+}
+
+func (b *Blank) Next() (Record, error) {
+	if b.remaining <= 0 {
+		return nil, io.EOF
+	}
+
+	b.remaining--
+
+	return &BlankRecord{}, nil
+}
+
+func (b *Blank) Close() error {
+	return nil
+}
+
+func (r *BlankRecord) Get(field string) (any, error) {
+	return nil, fmt.Errorf("There is no input, so there is no %s column", field)
+}
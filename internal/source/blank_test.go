@@ -0,0 +1,33 @@
+package source
+
+import (
+	"io"
+	"testing"
+)
+
+func TestBlank(t *testing.T) {
+	blank := NewBlank(3)
+	count := 0
+
+	for {
+		record, err := blank.Next()
+
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := record.Get("Name"); err == nil {
+			t.Errorf("Expected blank records to have no columns")
+		}
+
+		count++
+	}
+
+	if count != 3 {
+		t.Errorf("Expected %v, but got %v", 3, count)
+	}
+}
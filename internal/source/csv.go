@@ -0,0 +1,67 @@
+package source
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+)
+
+type Csv struct {
+	file   *os.File
+	reader *csv.Reader
+	header map[string]int
+}
+
+// Opens the csv file decoding it to utf-8. Names declared in the options
+// take precedence over the first line of the file
+func NewCsv(path string, options Options) (*Csv, error) {
+	file, err := os.Open(path)
+
+	if err != nil {
+		return nil, fmt.Errorf("Could not open the csv file: %s", err)
+	}
+
+	decoded, err := newDecodingReader(file, options.Encoding)
+
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	source := &Csv{
+		file:   file,
+		reader: csv.NewReader(decoded),
+		header: newHeader(options.Columns),
+	}
+
+	if !options.HasHeader {
+		return source, nil
+	}
+
+	line, err := source.reader.Read()
+
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("Could not read the csv file: %s", err)
+	}
+
+	if len(options.Columns) == 0 {
+		source.header = newHeader(line)
+	}
+
+	return source, nil
+}
+
+func (c *Csv) Next() (Record, error) {
+	line, err := c.reader.Read()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &Row{header: c.header, values: line}, nil
+}
+
+func (c *Csv) Close() error {
+	return c.file.Close()
+}
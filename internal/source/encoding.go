@@ -0,0 +1,90 @@
+package source
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
+)
+
+// How many bytes are read ahead to guess the encoding of a file
+const sniffSize = 4096
+
+var (
+	utf8BOM    = []byte{0xEF, 0xBB, 0xBF}
+	utf16LEBOM = []byte{0xFF, 0xFE}
+	utf16BEBOM = []byte{0xFE, 0xFF}
+)
+
+// Wraps r so everything read from it is utf-8 without a BOM. The encoding
+// can be "auto", in which case it is guessed from the BOM or from the content
+func newDecodingReader(r io.Reader, name string) (io.Reader, error) {
+	if strings.ToLower(name) != "auto" && name != "" {
+		enc, err := lookupEncoding(name)
+		if err != nil {
+			return nil, err
+		}
+
+		return transform.NewReader(r, enc.NewDecoder()), nil
+	}
+
+	buffered := bufio.NewReaderSize(r, sniffSize)
+	// Peek returns an error when the file is smaller than sniffSize, but
+	// we still get everything that was there
+	sample, _ := buffered.Peek(sniffSize)
+
+	return transform.NewReader(buffered, detectEncoding(sample).NewDecoder()), nil
+}
+
+func lookupEncoding(name string) (encoding.Encoding, error) {
+	switch strings.ToLower(name) {
+	case "utf-8", "utf8":
+		return unicode.UTF8BOM, nil
+	case "utf-16le", "utf16le":
+		return unicode.UTF16(unicode.LittleEndian, unicode.UseBOM), nil
+	case "utf-16be", "utf16be":
+		return unicode.UTF16(unicode.BigEndian, unicode.UseBOM), nil
+	case "latin1", "latin-1", "iso-8859-1":
+		return charmap.ISO8859_1, nil
+	case "windows-1252", "cp1252":
+		return charmap.Windows1252, nil
+	default:
+		return nil, fmt.Errorf("Unknown encoding %s", name)
+	}
+}
+
+func detectEncoding(sample []byte) encoding.Encoding {
+	switch {
+	case bytes.HasPrefix(sample, utf8BOM):
+		return unicode.UTF8BOM
+	case bytes.HasPrefix(sample, utf16LEBOM):
+		return unicode.UTF16(unicode.LittleEndian, unicode.UseBOM)
+	case bytes.HasPrefix(sample, utf16BEBOM):
+		return unicode.UTF16(unicode.BigEndian, unicode.UseBOM)
+	case isUTF8(sample):
+		return unicode.UTF8
+	default:
+		return charmap.ISO8859_1
+	}
+}
+
+func isUTF8(sample []byte) bool {
+	// The sample may end in the middle of a multi-byte character
+	for i := len(sample) - 1; i >= 0 && i >= len(sample)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(sample[i]) {
+			if !utf8.FullRune(sample[i:]) {
+				sample = sample[:i]
+			}
+			break
+		}
+	}
+
+	return utf8.Valid(sample)
+}
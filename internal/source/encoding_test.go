@@ -0,0 +1,97 @@
+package source
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func TestNewDecodingReader(t *testing.T) {
+	tests := []struct {
+		input      []byte
+		encoding   string
+		expected   string
+		shouldFail bool
+	}{
+		{
+			[]byte("Email,Age"),
+			"auto",
+			"Email,Age",
+			false,
+		},
+		{
+			append([]byte{0xEF, 0xBB, 0xBF}, []byte("Email,Age")...),
+			"auto",
+			"Email,Age",
+			false,
+		},
+		{
+			[]byte{0xFF, 0xFE, 'E', 0, 'm', 0, 'a', 0, 'i', 0, 'l', 0},
+			"auto",
+			"Email",
+			false,
+		},
+		{
+			[]byte{0xFE, 0xFF, 0, 'E', 0, 'm', 0, 'a', 0, 'i', 0, 'l'},
+			"auto",
+			"Email",
+			false,
+		},
+		{
+			[]byte{'J', 'o', 0xE3, 'o'},
+			"auto",
+			"João",
+			false,
+		},
+		{
+			[]byte{'J', 'o', 0xE3, 'o'},
+			"latin1",
+			"João",
+			false,
+		},
+		{
+			append([]byte{0xEF, 0xBB, 0xBF}, []byte("João")...),
+			"utf-8",
+			"João",
+			false,
+		},
+		{
+			[]byte{'E', 0, 'm', 0, 'a', 0, 'i', 0, 'l', 0},
+			"utf-16le",
+			"Email",
+			false,
+		},
+		{
+			[]byte("Email"),
+			"ebcdic",
+			"",
+			true,
+		},
+	}
+
+	for _, tt := range tests {
+		reader, err := newDecodingReader(bytes.NewReader(tt.input), tt.encoding)
+
+		if tt.shouldFail {
+			if err == nil {
+				t.Errorf("Expected %s to fail", tt.encoding)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+
+		got, err := io.ReadAll(reader)
+
+		if err != nil {
+			t.Error(err)
+		}
+
+		if string(got) != tt.expected {
+			t.Errorf("Expected %q, but got %q: %s", tt.expected, got, tt.encoding)
+		}
+	}
+}
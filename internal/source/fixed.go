@@ -0,0 +1,104 @@
+package source
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Longest line a fixed-width file may have
+const maxLineSize = 1024 * 1024
+
+// A column of a fixed-width file. Start is the position of its first
+// character, starting at 1
+type Column struct {
+	Name   string `yaml:"name"`
+	Start  int    `yaml:"start"`
+	Length int    `yaml:"length"`
+}
+
+// Reads files where each column takes a fixed number of characters. They
+// have no header, the names come from the layout instead
+type FixedWidth struct {
+	file    *os.File
+	scanner *bufio.Scanner
+	layout  []Column
+	header  map[string]int
+}
+
+func NewFixedWidth(path string, options Options) (*FixedWidth, error) {
+	if len(options.Layout) == 0 {
+		return nil, fmt.Errorf("Fixed-width files need a layout")
+	}
+
+	names := []string{}
+	for _, column := range options.Layout {
+		if column.Start < 1 || column.Length < 1 {
+			return nil, fmt.Errorf(
+				"Column %s should have a positive start and length, but has %d and %d",
+				column.Name,
+				column.Start,
+				column.Length,
+			)
+		}
+
+		names = append(names, column.Name)
+	}
+
+	file, err := os.Open(path)
+
+	if err != nil {
+		return nil, fmt.Errorf("Could not open the fixed-width file: %s", err)
+	}
+
+	decoded, err := newDecodingReader(file, options.Encoding)
+
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	scanner := bufio.NewScanner(decoded)
+	scanner.Buffer(nil, maxLineSize)
+
+	return &FixedWidth{
+		file:    file,
+		scanner: scanner,
+		layout:  options.Layout,
+		header:  newHeader(names),
+	}, nil
+}
+
+func (f *FixedWidth) Next() (Record, error) {
+	if !f.scanner.Scan() {
+		if err := f.scanner.Err(); err != nil {
+			return nil, err
+		}
+
+		return nil, io.EOF
+	}
+
+	return &Row{header: f.header, values: splitFixedWidth(f.scanner.Text(), f.layout)}, nil
+}
+
+func (f *FixedWidth) Close() error {
+	return f.file.Close()
+}
+
+// Positions are counted in characters, not bytes. The padding around each
+// value is removed and columns past the end of the line are empty
+func splitFixedWidth(line string, layout []Column) []string {
+	characters := []rune(line)
+	values := []string{}
+
+	for _, column := range layout {
+		start := min(column.Start-1, len(characters))
+		end := min(start+column.Length, len(characters))
+
+		values = append(values, strings.TrimSpace(string(characters[start:end])))
+	}
+
+	return values
+}
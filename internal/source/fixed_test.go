@@ -0,0 +1,44 @@
+package source
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitFixedWidth(t *testing.T) {
+	layout := []Column{
+		{Name: "Code", Start: 1, Length: 4},
+		{Name: "Name", Start: 5, Length: 10},
+		{Name: "Age", Start: 15, Length: 3},
+	}
+
+	tests := []struct {
+		line     string
+		expected []string
+	}{
+		{
+			"0001John      032",
+			[]string{"0001", "John", "032"},
+		},
+		{
+			"0002João Silva 27",
+			[]string{"0002", "João Silva", "27"},
+		},
+		{
+			"0003Mary",
+			[]string{"0003", "Mary", ""},
+		},
+		{
+			"",
+			[]string{"", "", ""},
+		},
+	}
+
+	for _, tt := range tests {
+		got := splitFixedWidth(tt.line, layout)
+
+		if !reflect.DeepEqual(tt.expected, got) {
+			t.Errorf("Expected %q, but got %q: %s", tt.expected, got, tt.line)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package source
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Reads either a json array of objects or one object after another, as in
+// ndjson files
+type Json struct {
+	file    *os.File
+	decoder *json.Decoder
+	array   bool
+}
+
+type JsonRecord struct {
+	value map[string]any
+}
+
+func NewJson(path string, options Options, array bool) (*Json, error) {
+	file, err := os.Open(path)
+
+	if err != nil {
+		return nil, fmt.Errorf("Could not open the json file: %s", err)
+	}
+
+	decoded, err := newDecodingReader(file, options.Encoding)
+
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(decoded)
+	decoder.UseNumber()
+
+	if array {
+		token, err := decoder.Token()
+
+		if err != nil {
+			file.Close()
+			return nil, fmt.Errorf("Could not read the json file: %s", err)
+		}
+
+		if delim, ok := token.(json.Delim); !ok || delim != '[' {
+			file.Close()
+			return nil, fmt.Errorf("Expected the json file to be an array, but found %v", token)
+		}
+	}
+
+	return &Json{file: file, decoder: decoder, array: array}, nil
+}
+
+func (j *Json) Next() (Record, error) {
+	if j.array && !j.decoder.More() {
+		return nil, io.EOF
+	}
+
+	value := map[string]any{}
+
+	if err := j.decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+
+	return &JsonRecord{value: value}, nil
+}
+
+func (j *Json) Close() error {
+	return j.file.Close()
+}
+
+// Nested fields are referenced with dotted paths such as address.city.
+// Arrays are indexed the same way, like tags.0. Values are strings, except
+// for null, which is inserted as NULL
+func (r *JsonRecord) Get(field string) (any, error) {
+	value, ok := lookupPath(r.value, field)
+
+	if !ok {
+		return nil, fmt.Errorf("Json object does not have a %s field", field)
+	}
+
+	if value == nil {
+		return nil, nil
+	}
+
+	text, err := stringify(value)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return text, nil
+}
+
+// Objects and arrays are kept as json
+func stringify(value any) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case map[string]any, []any:
+		encoded, err := json.Marshal(v)
+
+		if err != nil {
+			return "", err
+		}
+
+		return string(encoded), nil
+	default:
+		return fmt.Sprint(v), nil
+	}
+}
@@ -0,0 +1,120 @@
+package source
+
+import (
+	"io"
+	"os"
+	"path"
+	"reflect"
+	"testing"
+)
+
+func TestJsonRecordGet(t *testing.T) {
+	record := &JsonRecord{
+		value: map[string]any{
+			"email": "john@mail.com",
+			"age":   "32",
+			"a.b":   "dotted",
+			"address": map[string]any{
+				"city": "Recife",
+			},
+			"tags":    []any{"red", "blue"},
+			"deleted": nil,
+		},
+	}
+
+	tests := []struct {
+		field      string
+		expected   any
+		shouldFail bool
+	}{
+		{"email", "john@mail.com", false},
+		{"age", "32", false},
+		{"a.b", "dotted", false},
+		{"address.city", "Recife", false},
+		{"address", `{"city":"Recife"}`, false},
+		{"tags", `["red","blue"]`, false},
+		{"tags.1", "blue", false},
+		{"deleted", nil, false},
+		{"tags.2", nil, true},
+		{"address.street", nil, true},
+		{"email.domain", nil, true},
+		{"name", nil, true},
+	}
+
+	for _, tt := range tests {
+		got, err := record.Get(tt.field)
+
+		if tt.shouldFail && (err == nil || got != nil) {
+			t.Errorf("Expected \"%s\", to fail with nil but got %v", tt.field, got)
+			continue
+		}
+
+		if got != tt.expected && !tt.shouldFail {
+			t.Errorf("Expected %s, but got %s: %s", tt.expected, got, tt.field)
+		}
+	}
+}
+
+func TestJsonNext(t *testing.T) {
+	tests := []struct {
+		content  string
+		array    bool
+		expected []string
+	}{
+		{
+			`[{"id": 1}, {"id": 2.5}, {"id": "three"}]`,
+			true,
+			[]string{"1", "2.5", "three"},
+		},
+		{
+			"{\"id\": 1}\n{\"id\": 2.5}\n{\"id\": \"three\"}\n",
+			false,
+			[]string{"1", "2.5", "three"},
+		},
+		{
+			`[]`,
+			true,
+			[]string{},
+		},
+	}
+
+	for _, tt := range tests {
+		filePath := path.Join(t.TempDir(), "input.json")
+
+		if err := os.WriteFile(filePath, []byte(tt.content), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		source, err := NewJson(filePath, Options{}, tt.array)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got := []string{}
+		for {
+			record, err := source.Next()
+
+			if err == io.EOF {
+				break
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			id, err := record.Get("id")
+
+			if err != nil {
+				t.Error(err)
+			}
+
+			got = append(got, id.(string))
+		}
+		source.Close()
+
+		if !reflect.DeepEqual(tt.expected, got) {
+			t.Errorf("Expected %v, but got %v", tt.expected, got)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package source
+
+import (
+	"fmt"
+	"io"
+)
+
+// Reads several files one after the other as if they were a single one.
+// Each file is only opened once the previous one ends, so each one has its
+// own header
+type Multi struct {
+	paths   []string
+	options Options
+	current Source
+}
+
+func OpenAll(paths []string, options Options) (Source, error) {
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("No input files were given")
+	}
+
+	if len(paths) == 1 {
+		return Open(paths[0], options)
+	}
+
+	current, err := Open(paths[0], options)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &Multi{paths: paths[1:], options: options, current: current}, nil
+}
+
+func (m *Multi) Next() (Record, error) {
+	for {
+		record, err := m.current.Next()
+
+		if err != io.EOF || len(m.paths) == 0 {
+			return record, err
+		}
+
+		err = m.current.Close()
+		// Closed already, even when the next file can't be opened
+		m.current = nil
+
+		if err != nil {
+			return nil, err
+		}
+
+		next, err := Open(m.paths[0], m.options)
+
+		if err != nil {
+			return nil, err
+		}
+
+		m.current = next
+		m.paths = m.paths[1:]
+	}
+}
+
+func (m *Multi) Close() error {
+	if m.current == nil {
+		return nil
+	}
+
+	return m.current.Close()
+}
@@ -0,0 +1,73 @@
+package source
+
+import (
+	"io"
+	"os"
+	"path"
+	"testing"
+)
+
+func TestMultiNext(t *testing.T) {
+	directory := t.TempDir()
+	first, second := path.Join(directory, "first.csv"), path.Join(directory, "second.csv")
+
+	if err := os.WriteFile(first, []byte("id\n1\n2\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(second, []byte("id\n3\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		paths      []string
+		expected   []string
+		shouldFail bool
+	}{
+		{[]string{first, second}, []string{"1", "2", "3"}, false},
+		{[]string{first, path.Join(directory, "missing.csv")}, []string{"1", "2"}, true},
+	}
+
+	for _, tt := range tests {
+		source, err := OpenAll(tt.paths, Options{HasHeader: true})
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got := []string{}
+		for {
+			record, err := source.Next()
+
+			if err == io.EOF {
+				break
+			}
+
+			if err != nil {
+				if !tt.shouldFail {
+					t.Error(err)
+				}
+
+				break
+			}
+
+			value, _ := record.Get("id")
+			got = append(got, value.(string))
+		}
+
+		if len(got) != len(tt.expected) {
+			t.Errorf("Expected %v, but got %v", tt.expected, got)
+		}
+
+		for idx := range min(len(got), len(tt.expected)) {
+			if got[idx] != tt.expected[idx] {
+				t.Errorf("Expected %v, but got %v", tt.expected, got)
+			}
+		}
+
+		// The file read last is closed only once
+		if err := source.Close(); err != nil {
+			t.Errorf("Expected to close %v, but got %s", tt.paths, err)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package source
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/parquet-go/parquet-go/format"
+)
+
+// Values read from parquet files keep their types, so they are passed to
+// the database driver as they are. Timestamps and dates become time.Time
+// and times become text, as the schema of the file declares them
+type Parquet struct {
+	file   *os.File
+	schema *parquet.Schema
+	reader *parquet.Reader
+}
+
+type ParquetRecord struct {
+	value map[string]any
+}
+
+func NewParquet(path string) (*Parquet, error) {
+	file, err := os.Open(path)
+
+	if err != nil {
+		return nil, fmt.Errorf("Could not open the parquet file: %s", err)
+	}
+
+	info, err := file.Stat()
+
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("Could not open the parquet file: %s", err)
+	}
+
+	// NewReader panics on invalid files, so the file is opened beforehand
+	parquetFile, err := parquet.OpenFile(file, info.Size())
+
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("Could not read the parquet file: %s", err)
+	}
+
+	return &Parquet{file: file, schema: parquetFile.Schema(), reader: parquet.NewReader(parquetFile)}, nil
+}
+
+func (p *Parquet) Next() (Record, error) {
+	value := map[string]any{}
+
+	if err := p.reader.Read(&value); err != nil {
+		return nil, err
+	}
+
+	decodeLogical(p.schema, value)
+
+	return &ParquetRecord{value: value}, nil
+}
+
+// Replaces the integers stored for timestamps, dates and times with the
+// values they stand for. Groups and repeated fields are walked along with
+// the nodes of the schema describing them
+func decodeLogical(node parquet.Node, value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for _, field := range node.Fields() {
+			if fieldValue, ok := v[field.Name()]; ok {
+				v[field.Name()] = decodeLogical(field, fieldValue)
+			}
+		}
+
+		return v
+	case []any:
+		for idx := range v {
+			v[idx] = decodeLogical(node, v[idx])
+		}
+
+		return v
+	}
+
+	if !node.Leaf() || node.Type().LogicalType() == nil {
+		return value
+	}
+
+	var number int64
+	switch v := value.(type) {
+	case int32:
+		number = int64(v)
+	case int64:
+		number = v
+	default:
+		return value
+	}
+
+	logical := node.Type().LogicalType()
+	switch {
+	case logical.Timestamp != nil:
+		return time.Unix(0, number*unitNanoseconds(logical.Timestamp.Unit)).UTC()
+	case logical.Date != nil:
+		return time.Unix(number*24*60*60, 0).UTC()
+	case logical.Time != nil:
+		return time.Unix(0, number*unitNanoseconds(logical.Time.Unit)).UTC().Format("15:04:05.999999999")
+	default:
+		return value
+	}
+}
+
+func unitNanoseconds(unit format.TimeUnit) int64 {
+	switch {
+	case unit.Millis != nil:
+		return int64(time.Millisecond)
+	case unit.Micros != nil:
+		return int64(time.Microsecond)
+	default:
+		return 1
+	}
+}
+
+func (p *Parquet) Close() error {
+	p.reader.Close()
+
+	return p.file.Close()
+}
+
+// Nested fields are referenced with dotted paths, the same way as in json
+// objects. Groups and lists are inserted as json
+func (r *ParquetRecord) Get(field string) (any, error) {
+	value, ok := lookupPath(r.value, field)
+
+	if !ok {
+		return nil, fmt.Errorf("Parquet file does not have a %s field", field)
+	}
+
+	switch v := value.(type) {
+	case map[string]any, []any:
+		encoded, err := json.Marshal(v)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return string(encoded), nil
+	default:
+		return value, nil
+	}
+}
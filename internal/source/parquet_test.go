@@ -0,0 +1,93 @@
+package source
+
+import (
+	"io"
+	"path"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+type parquetUser struct {
+	Email  string  `parquet:"email"`
+	Age    int64   `parquet:"age"`
+	Score  float64 `parquet:"score"`
+	Active bool    `parquet:"active"`
+	// Stored as integers with the logical type in the schema
+	Joined  time.Time `parquet:"joined,timestamp(millisecond)"`
+	Born    int32     `parquet:"born,date"`
+	Opens   int32     `parquet:"opens"`
+	Address struct {
+		City string `parquet:"city"`
+	} `parquet:"address"`
+}
+
+func TestParquetNext(t *testing.T) {
+	filePath := path.Join(t.TempDir(), "input.parquet")
+	users := []parquetUser{
+		{Email: "john@mail.com", Age: 32, Score: 7.5, Active: true, Joined: time.Date(2024, 3, 1, 10, 30, 0, 0, time.UTC), Born: 7426, Opens: 32400000},
+		{Email: "mary@mail.com", Age: 27, Score: 9, Active: false, Joined: time.Date(2023, 12, 31, 23, 59, 59, 0, time.UTC), Born: 0, Opens: 48600500},
+	}
+	users[0].Address.City = "Recife"
+	users[1].Address.City = "Olinda"
+
+	// Struct tags can't declare times, so the schema is written out
+	schema := parquet.NewSchema("user", parquet.Group{
+		"email":   parquet.String(),
+		"age":     parquet.Int(64),
+		"score":   parquet.Leaf(parquet.DoubleType),
+		"active":  parquet.Leaf(parquet.BooleanType),
+		"joined":  parquet.Timestamp(parquet.Millisecond),
+		"born":    parquet.Date(),
+		"opens":   parquet.Time(parquet.Millisecond),
+		"address": parquet.Group{"city": parquet.String()},
+	})
+
+	if err := parquet.WriteFile(filePath, users, schema); err != nil {
+		t.Fatal(err)
+	}
+
+	source, err := NewParquet(filePath)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer source.Close()
+
+	got := [][]any{}
+	for {
+		record, err := source.Next()
+
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		row := []any{}
+		for _, field := range []string{"email", "age", "score", "active", "joined", "born", "opens", "address.city"} {
+			value, err := record.Get(field)
+
+			if err != nil {
+				t.Error(err)
+			}
+
+			row = append(row, value)
+		}
+
+		got = append(got, row)
+	}
+
+	expected := [][]any{
+		{"john@mail.com", int64(32), 7.5, true, time.Date(2024, 3, 1, 10, 30, 0, 0, time.UTC), time.Date(1990, 5, 2, 0, 0, 0, 0, time.UTC), "09:00:00", "Recife"},
+		{"mary@mail.com", int64(27), 9.0, false, time.Date(2023, 12, 31, 23, 59, 59, 0, time.UTC), time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC), "13:30:00.5", "Olinda"},
+	}
+
+	if !reflect.DeepEqual(expected, got) {
+		t.Errorf("Expected %v, but got %v", expected, got)
+	}
+}
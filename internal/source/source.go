@@ -0,0 +1,152 @@
+package source
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Where the values being inserted come from
+type Source interface {
+	// Returns the next record or io.EOF when there is nothing left
+	Next() (Record, error)
+	Close() error
+}
+
+// A single line, object or row of a Source
+type Record interface {
+	// Finds the value of a field in the record. Values are strings unless
+	// the source knows their types, as in parquet files
+	Get(field string) (any, error)
+}
+
+// A record made of positional values, like csv lines or spreadsheet rows
+type Row struct {
+	header map[string]int
+	values []string
+}
+
+type Options struct {
+	// csv, json, ndjson, xlsx, parquet, fixed or auto to pick it from the
+	// file extension
+	Format   string
+	Encoding string
+	// Whether the first line of the file names the columns
+	HasHeader bool
+	// Names given to the columns, replacing the header if there is one
+	Columns []string
+	// Name or position of the spreadsheet to be read. Defaults to the first one
+	Sheet string
+	// Columns of a fixed-width file
+	Layout []Column
+}
+
+func Open(path string, options Options) (Source, error) {
+	switch pickFormat(path, options) {
+	case "csv":
+		return NewCsv(path, options)
+	case "json":
+		return NewJson(path, options, true)
+	case "ndjson":
+		return NewJson(path, options, false)
+	case "xlsx":
+		return NewXlsx(path, options)
+	case "parquet":
+		return NewParquet(path)
+	case "fixed":
+		return NewFixedWidth(path, options)
+	default:
+		return nil, fmt.Errorf("Unknown input format %s", options.Format)
+	}
+}
+
+// Files with a layout are always fixed-width, as it has no use for the others
+func pickFormat(path string, options Options) string {
+	if options.Format != "" && options.Format != "auto" {
+		return strings.ToLower(options.Format)
+	}
+
+	if len(options.Layout) > 0 {
+		return "fixed"
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return "json"
+	case ".ndjson", ".jsonl":
+		return "ndjson"
+	case ".xlsx":
+		return "xlsx"
+	case ".parquet":
+		return "parquet"
+	default:
+		return "csv"
+	}
+}
+
+// Maps each column name to its position
+func newHeader(columns []string) map[string]int {
+	header := map[string]int{}
+
+	for idx, column := range columns {
+		header[column] = idx
+	}
+
+	return header
+}
+
+// Columns can be referenced by their name or by their position, like $1
+func (r *Row) Get(field string) (any, error) {
+	index, ok := r.header[field]
+
+	if position, found := strings.CutPrefix(field, "$"); found {
+		if number, err := strconv.Atoi(position); err == nil {
+			index, ok = number-1, number > 0
+		}
+	}
+
+	if !ok {
+		return nil, fmt.Errorf("Input does not have a %s column", field)
+	}
+
+	if index >= len(r.values) {
+		return nil, fmt.Errorf("Row has %d columns, but tried to get %s", len(r.values), field)
+	}
+
+	return r.values[index], nil
+}
+
+// Walks through nested objects and arrays using dotted paths like
+// address.city or tags.0. A key containing dots is matched as a whole first
+func lookupPath(object map[string]any, field string) (any, bool) {
+	if value, ok := object[field]; ok {
+		return value, true
+	}
+
+	var current any = object
+	for _, key := range strings.Split(field, ".") {
+		switch node := current.(type) {
+		case map[string]any:
+			value, ok := node[key]
+
+			if !ok {
+				return nil, false
+			}
+
+			current = value
+		case []any:
+			index, err := strconv.Atoi(key)
+
+			if err != nil || index < 0 || index >= len(node) {
+				return nil, false
+			}
+
+			current = node[index]
+		default:
+			return nil, false
+		}
+	}
+
+	return current, true
+}
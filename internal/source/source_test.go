@@ -0,0 +1,41 @@
+package source
+
+import (
+	"testing"
+)
+
+func TestRowGet(t *testing.T) {
+	record := &Row{
+		header: map[string]int{"Email": 0, "Age": 2},
+		values: []string{"john@mail.com", "John", "32"},
+	}
+
+	tests := []struct {
+		field      string
+		expected   any
+		shouldFail bool
+	}{
+		{"Email", "john@mail.com", false},
+		{"Age", "32", false},
+		{"$1", "john@mail.com", false},
+		{"$2", "John", false},
+		{"$3", "32", false},
+		{"$4", nil, true},
+		{"$0", nil, true},
+		{"$", nil, true},
+		{"UserName", nil, true},
+	}
+
+	for _, tt := range tests {
+		got, err := record.Get(tt.field)
+
+		if tt.shouldFail && err == nil {
+			t.Errorf("Expected \"%s\", to fail but got %s", tt.field, got)
+			continue
+		}
+
+		if got != tt.expected {
+			t.Errorf("Expected %v, but got %v: %s", tt.expected, got, tt.field)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package source
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/xuri/excelize/v2"
+)
+
+type Xlsx struct {
+	file   *excelize.File
+	rows   *excelize.Rows
+	header map[string]int
+}
+
+// Opens a spreadsheet of the file. Its first row is used as the header the
+// same way it is done for csv files
+func NewXlsx(path string, options Options) (*Xlsx, error) {
+	file, err := excelize.OpenFile(path)
+
+	if err != nil {
+		return nil, fmt.Errorf("Could not open the xlsx file: %s", err)
+	}
+
+	sheet, err := pickSheet(file.GetSheetList(), options.Sheet)
+
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	rows, err := file.Rows(sheet)
+
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("Could not read the %s sheet: %s", sheet, err)
+	}
+
+	source := &Xlsx{
+		file:   file,
+		rows:   rows,
+		header: newHeader(options.Columns),
+	}
+
+	if !options.HasHeader {
+		return source, nil
+	}
+
+	line, err := source.readRow()
+
+	if err != nil {
+		source.Close()
+		return nil, fmt.Errorf("Could not read the header of the %s sheet: %s", sheet, err)
+	}
+
+	if len(options.Columns) == 0 {
+		source.header = newHeader(line)
+	}
+
+	return source, nil
+}
+
+// Sheets can be picked by their name or by their position, starting at 1
+func pickSheet(sheets []string, sheet string) (string, error) {
+	if len(sheets) == 0 {
+		return "", fmt.Errorf("The xlsx file has no sheets")
+	}
+
+	if sheet == "" {
+		return sheets[0], nil
+	}
+
+	for _, name := range sheets {
+		if name == sheet {
+			return name, nil
+		}
+	}
+
+	if position, err := strconv.Atoi(sheet); err == nil && position > 0 && position <= len(sheets) {
+		return sheets[position-1], nil
+	}
+
+	return "", fmt.Errorf("The xlsx file does not have a %s sheet", sheet)
+}
+
+func (x *Xlsx) readRow() ([]string, error) {
+	if !x.rows.Next() {
+		if err := x.rows.Error(); err != nil {
+			return nil, err
+		}
+
+		return nil, io.EOF
+	}
+
+	return x.rows.Columns()
+}
+
+func (x *Xlsx) Next() (Record, error) {
+	line, err := x.readRow()
+
+	if err != nil {
+		return nil, err
+	}
+
+	// Trailing empty cells are not returned by excelize
+	for len(line) < len(x.header) {
+		line = append(line, "")
+	}
+
+	return &Row{header: x.header, values: line}, nil
+}
+
+func (x *Xlsx) Close() error {
+	x.rows.Close()
+
+	return x.file.Close()
+}
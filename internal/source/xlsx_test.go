@@ -0,0 +1,97 @@
+package source
+
+import (
+	"io"
+	"path"
+	"reflect"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+)
+
+func TestPickSheet(t *testing.T) {
+	sheets := []string{"Users", "Houses", "2"}
+
+	tests := []struct {
+		sheet      string
+		expected   string
+		shouldFail bool
+	}{
+		{"", "Users", false},
+		{"Houses", "Houses", false},
+		{"1", "Users", false},
+		{"2", "2", false},
+		{"3", "2", false},
+		{"4", "", true},
+		{"0", "", true},
+		{"Cars", "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := pickSheet(sheets, tt.sheet)
+
+		if tt.shouldFail && err == nil {
+			t.Errorf("Expected \"%s\", to fail but got %s", tt.sheet, got)
+			continue
+		}
+
+		if got != tt.expected && !tt.shouldFail {
+			t.Errorf("Expected %s, but got %s: %s", tt.expected, got, tt.sheet)
+		}
+	}
+}
+
+func TestXlsxNext(t *testing.T) {
+	filePath := path.Join(t.TempDir(), "input.xlsx")
+	file := excelize.NewFile()
+	file.NewSheet("Users")
+	file.SetSheetRow("Users", "A1", &[]string{"Email", "Age", "Color"})
+	file.SetSheetRow("Users", "A2", &[]any{"john@mail.com", 32, "red"})
+	file.SetSheetRow("Users", "A3", &[]any{"mary@mail.com", 27})
+
+	if err := file.SaveAs(filePath); err != nil {
+		t.Fatal(err)
+	}
+
+	source, err := NewXlsx(filePath, Options{HasHeader: true, Sheet: "Users"})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer source.Close()
+
+	got := [][]string{}
+	for {
+		record, err := source.Next()
+
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		row := []string{}
+		for _, column := range []string{"Email", "Age", "Color"} {
+			value, err := record.Get(column)
+
+			if err != nil {
+				t.Error(err)
+			}
+
+			row = append(row, value.(string))
+		}
+
+		got = append(got, row)
+	}
+
+	expected := [][]string{
+		{"john@mail.com", "32", "red"},
+		{"mary@mail.com", "27", ""},
+	}
+
+	if !reflect.DeepEqual(expected, got) {
+		t.Errorf("Expected %v, but got %v", expected, got)
+	}
+}
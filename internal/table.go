@@ -2,26 +2,57 @@ package internal
 
 import (
 	"fmt"
+	"slices"
+	"strconv"
 	"strings"
 )
 
 type Table struct {
-	name   string
+	name string
+	// The name of the input the values come from
+	source string
 	fields map[string]Insertable
-	order  []string
+	// Values inserted as NULL for each field
+	nulls map[string][]string
+	// Converts the values of each field to the type declared for it
+	converters map[string]converter
+	// The insertion only happens when it is true. Nil for always
+	condition Insertable
+	// Fields identifying a row. Rows with the same values are inserted once
+	key []string
+	// What happens when the row violates a unique constraint. Nil to fail
+	conflict *Conflict
+	// Splits a value into the elements a row is inserted for. Nil for a
+	// single row
+	explode *explosion
+	// Name the insertion is referenced by, as in __table.alias__
+	alias string
+	// Column read as the id of the rows. Empty for the default of the database
+	id string
+	// Whether other tables reference the rows, so their ids are read back
+	referenced bool
+	// Columns read back after inserting, for references like __table__.column
+	returning []string
+	// Field generated by sozza used as the id instead of the database one
+	generatedId string
+	order       []string
 }
 
-func newTable(name string, fields map[string]Insertable) *Table {
+func newTable(name string, source string, fields map[string]Insertable) *Table {
 	order := []string{}
 
 	for field := range fields {
 		order = append(order, field)
 	}
 
-	return &Table{name: name, fields: fields, order: order}
+	return &Table{name: name, source: source, fields: fields, order: order}
 }
 
-func (t *Table) createStatment() string {
+func (t *Table) createStatment(dialect string) string {
+	if t.conflict.handled() {
+		return bindParams(t.conflict.insertStatement(t.name, t.order)+t.returningClause(dialect), dialect)
+	}
+
 	placeholders := strings.Repeat("?, ", len(t.fields))
 	placeholders, _ = strings.CutSuffix(placeholders, ", ")
 
@@ -32,7 +63,95 @@ func (t *Table) createStatment() string {
 		placeholders,
 	)
 
-	return statment
+	return bindParams(statment+t.returningClause(dialect), dialect)
+}
+
+// Whether the id and the referenced columns come from RETURNING, which both
+// postgres and sqlite have. Postgres reads the id that way, since its driver
+// has no LastInsertId, and so do tables whose id is not the default column.
+// Nothing is read for tables no one references
+func (t *Table) returnsRow(dialect string) bool {
+	return t.referenced && (len(t.returning) > 0 || dialect == "postgres" || t.id != "")
+}
+
+func (t *Table) returningClause(dialect string) string {
+	if !t.returnsRow(dialect) {
+		return ""
+	}
+
+	columns := append([]string{t.idColumn(dialect)}, t.returning...)
+
+	return " RETURNING " + strings.Join(columns, ", ")
+}
+
+// Statements are written with ? placeholders, but postgres numbers them, as
+// in $1. Quoted text is left as it is
+func bindParams(statement string, dialect string) string {
+	if dialect != "postgres" {
+		return statement
+	}
+
+	var builder strings.Builder
+	var quote rune
+	count := 0
+
+	for _, char := range statement {
+		switch {
+		case quote != 0:
+			if char == quote {
+				quote = 0
+			}
+		case char == '\'' || char == '"' || char == '`':
+			quote = char
+		case char == '?':
+			count++
+			builder.WriteString("$" + strconv.Itoa(count))
+			continue
+		}
+
+		builder.WriteRune(char)
+	}
+
+	return builder.String()
+}
+
+// The column used as the id of the rows. rowid for sqlite and id for the
+// other databases, unless the mapping says otherwise or the id is generated
+func (t *Table) idColumn(dialect string) string {
+	switch {
+	case t.id != "":
+		return t.id
+	case t.generatedId != "":
+		return t.generatedId
+	case dialect == "sqlite":
+		return "rowid"
+	default:
+		return "id"
+	}
+}
+
+func (t *Table) selectStatement(columns []string, where []string) string {
+	conditions := []string{}
+
+	for _, column := range where {
+		conditions = append(conditions, column+" = ?")
+	}
+
+	return fmt.Sprintf("SELECT %s FROM %s WHERE %s", strings.Join(columns, ", "), t.name, strings.Join(conditions, " AND "))
+}
+
+func (t *Table) shouldInsert(context InsertContext) (bool, error) {
+	if t.condition == nil {
+		return true, nil
+	}
+
+	value, err := t.condition.generateValue(context)
+
+	if err != nil {
+		return false, fmt.Errorf("Error evaluating the condition of %s: %s", t.name, err)
+	}
+
+	return isTruthy(value), nil
 }
 
 func (t *Table) buildValues(context InsertContext) ([]any, error) {
@@ -46,20 +165,100 @@ func (t *Table) buildValues(context InsertContext) ([]any, error) {
 			return nil, fmt.Errorf("Error generating value for %s:%s: %s", t.name, field, err)
 		}
 
+		if text, ok := value.(string); ok && slices.Contains(t.nulls[field], text) {
+			value = nil
+		}
+
+		if convert, ok := t.converters[field]; ok {
+			value, err = convert(value)
+
+			if err != nil {
+				return nil, fmt.Errorf("Error converting value for %s:%s: %s", t.name, field, err)
+			}
+		}
+
 		values = append(values, value)
 	}
 
 	return values, nil
 }
 
-func (t *Table) findReferences() []TableReference {
-	references := []TableReference{}
-	for _, insertable := range t.fields {
-		switch value := insertable.(type) {
-		case *TableReference:
-			references = append(references, *value)
+// Encodes the values of the key fields, taken from the values built for
+// the statement. NULL is kept apart from the empty string
+func (t *Table) naturalKey(values []any) (string, bool) {
+	if len(t.key) == 0 {
+		return "", false
+	}
+
+	parts := []string{}
+	for _, field := range t.key {
+		value := values[slices.Index(t.order, field)]
+
+		if value == nil {
+			parts = append(parts, "NULL")
+		} else {
+			parts = append(parts, strconv.Quote(toString(value)))
 		}
 	}
 
+	return strings.Join(parts, ", "), true
+}
+
+// The values of the columns of the conflict, used to find the row
+func (t *Table) conflictValues(values []any) []any {
+	conflicting := []any{}
+
+	for _, column := range t.conflict.On {
+		conflicting = append(conflicting, values[slices.Index(t.order, column)])
+	}
+
+	return conflicting
+}
+
+// References are also found inside function arguments and the condition
+func (t *Table) findReferences() []*TableReference {
+	references := []*TableReference{}
+	for _, insertable := range t.fields {
+		references = append(references, collectReferences(insertable)...)
+	}
+
+	if t.condition != nil {
+		references = append(references, collectReferences(t.condition)...)
+	}
+
+	return references
+}
+
+func collectReferences(insertable Insertable) []*TableReference {
+	references := []*TableReference{}
+
+	if reference, ok := insertable.(*TableReference); ok {
+		references = append(references, reference)
+	}
+
+	for _, child := range childrenOf(insertable) {
+		references = append(references, collectReferences(child)...)
+	}
+
 	return references
 }
+
+// Values an insertable is computed from, like the arguments of a function
+func childrenOf(insertable Insertable) []Insertable {
+	switch value := insertable.(type) {
+	case *Function:
+		return value.args
+	case *Fake:
+		return value.args
+	case *Lookup:
+		return value.values
+	case *Comparison:
+		return []Insertable{value.left, value.right}
+	case *Logical:
+		return value.operands
+	case *Not:
+		return []Insertable{value.operand}
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,111 @@
+package internal
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBuildValuesWithNulls(t *testing.T) {
+	table := newTable("user", "", map[string]Insertable{
+		"email": &Literal{value: ""},
+		"age":   &Literal{value: "N/A"},
+		"name":  &Literal{value: "John"},
+		"score": &Literal{value: int64(0)},
+	})
+	table.order = []string{"email", "age", "name", "score"}
+	table.nulls = map[string][]string{
+		"email": {""},
+		"age":   {"", "N/A"},
+		"score": {"0"},
+	}
+
+	got, err := table.buildValues(InsertContext{})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []any{nil, nil, "John", int64(0)}
+
+	if !reflect.DeepEqual(expected, got) {
+		t.Errorf("Expected %v, but got %v", expected, got)
+	}
+}
+
+func TestNaturalKey(t *testing.T) {
+	table := newTable("house", "", map[string]Insertable{})
+	table.order = []string{"house_color", "city", "house_size"}
+	table.key = []string{"house_color", "house_size"}
+
+	tests := []struct {
+		values   []any
+		expected string
+	}{
+		{[]any{"red", "Recife", int64(3)}, `"red", "3"`},
+		{[]any{"red", "Natal", "3"}, `"red", "3"`},
+		{[]any{"", "Recife", nil}, `"", NULL`},
+		{[]any{"a\", \"b", "Recife", "c"}, `"a\", \"b", "c"`},
+	}
+
+	for _, tt := range tests {
+		got, ok := table.naturalKey(tt.values)
+
+		if !ok || got != tt.expected {
+			t.Errorf("Expected %v, but got %v", tt.expected, got)
+		}
+	}
+
+	if _, ok := newTable("user", "", map[string]Insertable{}).naturalKey([]any{}); ok {
+		t.Errorf("Expected a table without a key to have no natural key")
+	}
+}
+
+func TestCreateStatment(t *testing.T) {
+	tests := []struct {
+		referenced bool
+		returning  []string
+		id         string
+		dialect    string
+		expected   string
+	}{
+		{false, nil, "", "postgres", "INSERT INTO user (name) VALUES ($1)"},
+		{true, nil, "", "postgres", "INSERT INTO user (name) VALUES ($1) RETURNING id"},
+		{true, nil, "code", "postgres", "INSERT INTO user (name) VALUES ($1) RETURNING code"},
+		{true, nil, "", "sqlite", "INSERT INTO user (name) VALUES (?)"},
+		{true, nil, "code", "sqlite", "INSERT INTO user (name) VALUES (?) RETURNING code"},
+		{true, []string{"slug"}, "", "sqlite", "INSERT INTO user (name) VALUES (?) RETURNING rowid, slug"},
+	}
+
+	for _, tt := range tests {
+		table := newTable("user", "", map[string]Insertable{"name": &RegularInsertion{"Name"}})
+		table.referenced = tt.referenced
+		table.returning = tt.returning
+		table.id = tt.id
+
+		got := table.createStatment(tt.dialect)
+
+		if got != tt.expected {
+			t.Errorf("Expected %v, but got %v", tt.expected, got)
+		}
+	}
+}
+
+func TestBindParams(t *testing.T) {
+	tests := []struct {
+		statement string
+		dialect   string
+		expected  string
+	}{
+		{"INSERT INTO user (a, b) VALUES (?, ?)", "sqlite", "INSERT INTO user (a, b) VALUES (?, ?)"},
+		{"INSERT INTO user (a, b) VALUES (?, ?)", "postgres", "INSERT INTO user (a, b) VALUES ($1, $2)"},
+		{`SELECT "what?" FROM user WHERE a = '?' AND b = ?`, "postgres", `SELECT "what?" FROM user WHERE a = '?' AND b = $1`},
+	}
+
+	for _, tt := range tests {
+		got := bindParams(tt.statement, tt.dialect)
+
+		if got != tt.expected {
+			t.Errorf("Expected %v, but got %v", tt.expected, got)
+		}
+	}
+}
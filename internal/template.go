@@ -0,0 +1,156 @@
+package internal
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+	"text/template/parse"
+)
+
+// A value built from a text/template, such as {{.FirstName}} {{.LastName}}.
+// Columns are the fields of the template data. Those with spaces or other
+// symbols are used with index, like {{index . "First Name"}}
+type Template struct {
+	template *template.Template
+	// Columns used in the template, found when it is parsed
+	fields  [][]string
+	columns []string
+}
+
+func newTemplate(text string) (*Template, error) {
+	parsed, err := template.New("value").
+		Option("missingkey=error").
+		Funcs(templateFunctions()).
+		Parse(text)
+
+	if err != nil {
+		return nil, fmt.Errorf("Invalid template %s: %s", text, err)
+	}
+
+	t := &Template{template: parsed}
+	t.findColumns(parsed.Tree.Root, false)
+
+	return t, nil
+}
+
+// Builtin functions are available in templates as well. When piping, the
+// value goes as the last argument, so only functions taking one argument
+// should be used that way
+func templateFunctions() template.FuncMap {
+	functions := template.FuncMap{}
+
+	for name, function := range builtins {
+		functions[name] = func(args ...any) (any, error) {
+			if len(args) < function.minArgs || (function.maxArgs >= 0 && len(args) > function.maxArgs) {
+				return nil, fmt.Errorf("%s can't take %d arguments", name, len(args))
+			}
+
+			return function.call(args)
+		}
+	}
+
+	return functions
+}
+
+// The record can't list its columns, so the ones used by the template are
+// collected beforehand and read for each record. Inside range and with the
+// dot changes, so only columns used through $ are collected there
+func (t *Template) findColumns(node parse.Node, scoped bool) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+
+		for _, child := range n.Nodes {
+			t.findColumns(child, scoped)
+		}
+	case *parse.ActionNode:
+		t.findColumns(n.Pipe, scoped)
+	case *parse.PipeNode:
+		if n == nil {
+			return
+		}
+
+		for _, command := range n.Cmds {
+			t.findColumns(command, scoped)
+		}
+	case *parse.CommandNode:
+		if len(n.Args) == 3 && n.Args[0].String() == "index" && n.Args[1].Type() == parse.NodeDot && !scoped {
+			if column, ok := n.Args[2].(*parse.StringNode); ok {
+				t.columns = append(t.columns, column.Text)
+			}
+		}
+
+		for _, arg := range n.Args {
+			t.findColumns(arg, scoped)
+		}
+	case *parse.FieldNode:
+		if !scoped {
+			t.fields = append(t.fields, n.Ident)
+		}
+	case *parse.VariableNode:
+		if len(n.Ident) > 1 && n.Ident[0] == "$" {
+			t.fields = append(t.fields, n.Ident[1:])
+		}
+	case *parse.IfNode:
+		t.findColumns(n.Pipe, scoped)
+		t.findColumns(n.List, scoped)
+		t.findColumns(n.ElseList, scoped)
+	case *parse.RangeNode:
+		t.findColumns(n.Pipe, scoped)
+		t.findColumns(n.List, true)
+		t.findColumns(n.ElseList, scoped)
+	case *parse.WithNode:
+		t.findColumns(n.Pipe, scoped)
+		t.findColumns(n.List, true)
+		t.findColumns(n.ElseList, scoped)
+	}
+}
+
+func (t *Template) generateValue(context InsertContext) (any, error) {
+	data := map[string]any{}
+
+	for _, field := range t.fields {
+		value, err := context.record.Get(strings.Join(field, "."))
+
+		if err != nil {
+			return nil, err
+		}
+
+		setPath(data, field, value)
+	}
+
+	for _, column := range t.columns {
+		value, err := context.record.Get(column)
+
+		if err != nil {
+			return nil, err
+		}
+
+		data[column] = value
+	}
+
+	text := strings.Builder{}
+	if err := t.template.Execute(&text, data); err != nil {
+		return nil, fmt.Errorf("Error executing template: %s", err)
+	}
+
+	return text.String(), nil
+}
+
+// Dotted fields like .address.city become nested maps
+func setPath(data map[string]any, path []string, value any) {
+	for _, key := range path[:len(path)-1] {
+		nested, ok := data[key].(map[string]any)
+
+		if !ok {
+			nested = map[string]any{}
+			data[key] = nested
+		}
+
+		data = nested
+	}
+
+	data[path[len(path)-1]] = value
+}
@@ -0,0 +1,76 @@
+package internal
+
+import (
+	"fmt"
+	"testing"
+)
+
+// A record for tests, where dotted fields are plain keys
+type mapRecord map[string]any
+
+func (m mapRecord) Get(field string) (any, error) {
+	value, ok := m[field]
+
+	if !ok {
+		return nil, fmt.Errorf("Input does not have a %s column", field)
+	}
+
+	return value, nil
+}
+
+func TestTemplate(t *testing.T) {
+	context := InsertContext{
+		record: mapRecord{
+			"FirstName":    "John",
+			"LastName":     "Doe",
+			"Last Name":    "Silva",
+			"address.city": "Recife",
+			"address.zip":  "50000",
+			"Nickname":     "",
+		},
+	}
+
+	tests := []struct {
+		text       string
+		expected   string
+		shouldFail bool
+	}{
+		{"{{.FirstName}} {{.LastName}}", "John Doe", false},
+		{`{{.FirstName}} {{index . "Last Name"}}`, "John Silva", false},
+		{"{{.address.city}} - {{.address.zip}}", "Recife - 50000", false},
+		{"{{.FirstName | upper}}", "JOHN", false},
+		{`{{if .Nickname}}{{.Nickname}}{{else}}{{.FirstName}}{{end}}`, "John", false},
+		{`{{with .FirstName}}{{.}} {{$.LastName}}{{end}}`, "John Doe", false},
+		{`{{replace .FirstName "o" "0"}}`, "J0hn", false},
+		{"{{.MiddleName}}", "", true},
+		{"{{.FirstName", "", true},
+	}
+
+	for _, tt := range tests {
+		template, err := newTemplate(tt.text)
+
+		if err != nil {
+			if !tt.shouldFail {
+				t.Error(err)
+			}
+			continue
+		}
+
+		got, err := template.generateValue(context)
+
+		if tt.shouldFail {
+			if err == nil {
+				t.Errorf("Expected \"%s\", to fail but got %v", tt.text, got)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Error(err)
+		}
+
+		if got != tt.expected {
+			t.Errorf("Expected %s, but got %v: %s", tt.expected, got, tt.text)
+		}
+	}
+}
@@ -0,0 +1,286 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	_ "time/tzdata"
+
+	"github.com/google/uuid"
+	"gopkg.in/yaml.v3"
+)
+
+// The type of a field, declared either just by its name or with the options
+// used to parse dates and timestamps
+type FieldType struct {
+	Name string `yaml:"type"`
+	// Layouts tried in order when parsing dates and timestamps. Besides Go
+	// layouts, it can be iso8601, epoch or epoch_ms
+	Layouts []string `yaml:"layouts"`
+	// Timezone of the values that don't have their own
+	Timezone string `yaml:"timezone"`
+}
+
+// Converts a generated value to the type declared for its field
+type converter func(value any) (any, error)
+
+var converters = map[string]converter{
+	"int":       toInt,
+	"float":     toFloat,
+	"bool":      toBool,
+	"date":      toDate,
+	"timestamp": toTimestamp,
+	"uuid":      toUUID,
+	"json":      toJSON,
+	"bytes":     toBytes,
+}
+
+var (
+	dateLayouts = []string{
+		time.DateOnly,
+	}
+	timestampLayouts = []string{
+		time.RFC3339Nano,
+		"2006-01-02T15:04:05",
+		time.DateTime,
+		time.DateOnly,
+	}
+	namedLayouts = map[string][]string{
+		"iso8601": {time.RFC3339Nano, "2006-01-02T15:04:05", time.DateOnly},
+		"rfc3339": {time.RFC3339Nano},
+	}
+)
+
+func (f *FieldType) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		return node.Decode(&f.Name)
+	}
+
+	// Avoids calling UnmarshalYAML again
+	type plain FieldType
+
+	return node.Decode((*plain)(f))
+}
+
+// NULL is valid for every type, so nil is never converted
+func newConverter(fieldType FieldType) (converter, error) {
+	convert, ok := converters[fieldType.Name]
+
+	if !ok {
+		return nil, fmt.Errorf("Unknown type %s", fieldType.Name)
+	}
+
+	if len(fieldType.Layouts) > 0 || fieldType.Timezone != "" {
+		if fieldType.Name != "date" && fieldType.Name != "timestamp" {
+			return nil, fmt.Errorf("Only dates and timestamps have layouts and timezones, but %s was given", fieldType.Name)
+		}
+
+		var err error
+		if convert, err = newTimeConverter(fieldType); err != nil {
+			return nil, err
+		}
+	}
+
+	return func(value any) (any, error) {
+		if value == nil {
+			return nil, nil
+		}
+
+		converted, err := convert(value)
+
+		if err != nil {
+			return nil, fmt.Errorf("Could not convert %q to %s: %s", toString(value), fieldType.Name, err)
+		}
+
+		return converted, nil
+	}, nil
+}
+
+func toInt(value any) (any, error) {
+	switch v := value.(type) {
+	case int64:
+		return v, nil
+	case int:
+		return int64(v), nil
+	case int32:
+		return int64(v), nil
+	case float32:
+		return floatToInt(float64(v))
+	case float64:
+		return floatToInt(v)
+	default:
+		return strconv.ParseInt(strings.TrimSpace(toString(value)), 10, 64)
+	}
+}
+
+// Floats beyond the range of int64 would wrap around when converted
+func floatToInt(value float64) (any, error) {
+	if value != math.Trunc(value) {
+		return nil, fmt.Errorf("it has decimals")
+	}
+
+	if value < math.MinInt64 || value >= math.MaxInt64 {
+		return nil, fmt.Errorf("it is out of the range of an int")
+	}
+
+	return int64(value), nil
+}
+
+func toFloat(value any) (any, error) {
+	switch v := value.(type) {
+	case float64:
+		return v, nil
+	case float32:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	case int:
+		return float64(v), nil
+	case int32:
+		return float64(v), nil
+	default:
+		return strconv.ParseFloat(strings.TrimSpace(toString(value)), 64)
+	}
+}
+
+func toBool(value any) (any, error) {
+	if v, ok := value.(bool); ok {
+		return v, nil
+	}
+
+	switch strings.ToLower(strings.TrimSpace(toString(value))) {
+	case "true", "t", "yes", "y", "1":
+		return true, nil
+	case "false", "f", "no", "n", "0":
+		return false, nil
+	default:
+		return nil, fmt.Errorf("it is not a boolean")
+	}
+}
+
+func toDate(value any) (any, error) {
+	return parseTime(value, dateLayouts, time.UTC)
+}
+
+func toTimestamp(value any) (any, error) {
+	return parseTime(value, timestampLayouts, time.UTC)
+}
+
+func newTimeConverter(fieldType FieldType) (converter, error) {
+	location := time.UTC
+
+	if fieldType.Timezone != "" {
+		var err error
+		if location, err = time.LoadLocation(fieldType.Timezone); err != nil {
+			return nil, fmt.Errorf("Unknown timezone %s", fieldType.Timezone)
+		}
+	}
+
+	layouts := fieldType.Layouts
+	if len(layouts) == 0 && fieldType.Name == "date" {
+		layouts = dateLayouts
+	} else if len(layouts) == 0 {
+		layouts = timestampLayouts
+	}
+
+	expanded := []string{}
+	for _, layout := range layouts {
+		name := strings.ToLower(layout)
+
+		switch named, ok := namedLayouts[name]; {
+		case ok:
+			expanded = append(expanded, named...)
+		case name == "epoch" || name == "epoch_ms":
+			expanded = append(expanded, name)
+		default:
+			expanded = append(expanded, layout)
+		}
+	}
+
+	return func(value any) (any, error) {
+		return parseTime(value, expanded, location)
+	}, nil
+}
+
+// Values without a timezone are taken as being in location
+func parseTime(value any, layouts []string, location *time.Location) (any, error) {
+	if v, ok := value.(time.Time); ok {
+		return v, nil
+	}
+
+	text := strings.TrimSpace(toString(value))
+	for _, layout := range layouts {
+		switch layout {
+		case "epoch", "epoch_ms":
+			if parsed, ok := parseEpoch(value, layout == "epoch_ms"); ok {
+				return parsed.In(location), nil
+			}
+		default:
+			if parsed, err := time.ParseInLocation(layout, text, location); err == nil {
+				return parsed, nil
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("it does not match any of %s", strings.Join(layouts, ", "))
+}
+
+func parseEpoch(value any, milliseconds bool) (time.Time, bool) {
+	converted, err := toFloat(value)
+
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	seconds := converted.(float64)
+	if milliseconds {
+		seconds /= 1000
+	}
+
+	whole, fraction := math.Modf(seconds)
+
+	return time.Unix(int64(whole), int64(fraction*float64(time.Second))), true
+}
+
+// Uuids are passed as strings in their canonical form
+func toUUID(value any) (any, error) {
+	parsed, err := uuid.Parse(strings.TrimSpace(toString(value)))
+
+	if err != nil {
+		return nil, err
+	}
+
+	return parsed.String(), nil
+}
+
+// Strings must already be valid json. Anything else is encoded
+func toJSON(value any) (any, error) {
+	text, ok := value.(string)
+
+	if !ok {
+		encoded, err := json.Marshal(value)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return string(encoded), nil
+	}
+
+	if !json.Valid([]byte(text)) {
+		return nil, fmt.Errorf("it is not valid json")
+	}
+
+	return text, nil
+}
+
+func toBytes(value any) (any, error) {
+	if v, ok := value.([]byte); ok {
+		return v, nil
+	}
+
+	return []byte(toString(value)), nil
+}
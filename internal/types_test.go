@@ -0,0 +1,180 @@
+package internal
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestConverters(t *testing.T) {
+	tests := []struct {
+		value      any
+		typeName   string
+		expected   any
+		shouldFail bool
+	}{
+		{"42", "int", int64(42), false},
+		{" -7 ", "int", int64(-7), false},
+		{int64(3), "int", int64(3), false},
+		{3.0, "int", int64(3), false},
+		{3.5, "int", nil, true},
+		{int32(5), "int", int64(5), false},
+		{float32(4), "int", int64(4), false},
+		{-9223372036854775808.0, "int", int64(-9223372036854775808), false},
+		{9223372036854775808.0, "int", nil, true},
+		{-1e19, "int", nil, true},
+		{"forty", "int", nil, true},
+		{"3.14", "float", 3.14, false},
+		{int64(2), "float", 2.0, false},
+		{2, "float", 2.0, false},
+		{int32(-3), "float", -3.0, false},
+		{"abc", "float", nil, true},
+		{"true", "bool", true, false},
+		{"N", "bool", false, false},
+		{"1", "bool", true, false},
+		{"maybe", "bool", nil, true},
+		{"2024-02-29", "date", time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC), false},
+		{"29/02/2024", "date", nil, true},
+		{"2024-02-29 10:30:00", "timestamp", time.Date(2024, 2, 29, 10, 30, 0, 0, time.UTC), false},
+		{"2024-02-29T10:30:00Z", "timestamp", time.Date(2024, 2, 29, 10, 30, 0, 0, time.UTC), false},
+		{"yesterday", "timestamp", nil, true},
+		{"6BA7B810-9DAD-11D1-80B4-00C04FD430C8", "uuid", "6ba7b810-9dad-11d1-80b4-00c04fd430c8", false},
+		{"not-a-uuid", "uuid", nil, true},
+		{`{"a": 1}`, "json", `{"a": 1}`, false},
+		{int64(1), "json", "1", false},
+		{`{"a": `, "json", nil, true},
+		{"raw", "bytes", []byte("raw"), false},
+		{nil, "int", nil, false},
+		{"1", "decimal", nil, true},
+	}
+
+	for _, tt := range tests {
+		convert, err := newConverter(FieldType{Name: tt.typeName})
+
+		if err != nil {
+			if !tt.shouldFail {
+				t.Error(err)
+			}
+			continue
+		}
+
+		got, err := convert(tt.value)
+
+		if tt.shouldFail {
+			if err == nil {
+				t.Errorf("Expected %v to fail as %s, but got %v", tt.value, tt.typeName, got)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Error(err)
+		}
+
+		if !reflect.DeepEqual(tt.expected, got) {
+			t.Errorf("Expected %v, but got %v: %v as %s", tt.expected, got, tt.value, tt.typeName)
+		}
+	}
+}
+
+func TestTimeConverters(t *testing.T) {
+	saoPaulo, _ := time.LoadLocation("America/Sao_Paulo")
+
+	tests := []struct {
+		value      any
+		fieldType  FieldType
+		expected   time.Time
+		shouldFail bool
+	}{
+		{
+			"29/02/2024",
+			FieldType{Name: "date", Layouts: []string{"02/01/2006"}},
+			time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC),
+			false,
+		},
+		{
+			"2024-02-29T10:30:00-03:00",
+			FieldType{Name: "timestamp", Layouts: []string{"02/01/2006", "iso8601"}},
+			time.Date(2024, 2, 29, 13, 30, 0, 0, time.UTC),
+			false,
+		},
+		{
+			"29/02/2024 10:30",
+			FieldType{Name: "timestamp", Layouts: []string{"02/01/2006 15:04"}, Timezone: "America/Sao_Paulo"},
+			time.Date(2024, 2, 29, 10, 30, 0, 0, saoPaulo),
+			false,
+		},
+		{
+			"1709202600",
+			FieldType{Name: "timestamp", Layouts: []string{"iso8601", "epoch"}},
+			time.Date(2024, 2, 29, 10, 30, 0, 0, time.UTC),
+			false,
+		},
+		{
+			int64(1709202600500),
+			FieldType{Name: "timestamp", Layouts: []string{"EPOCH_MS"}},
+			time.Date(2024, 2, 29, 10, 30, 0, int(500*time.Millisecond), time.UTC),
+			false,
+		},
+		{
+			"2024-02-29 10:30:00",
+			FieldType{Name: "timestamp", Timezone: "America/Sao_Paulo"},
+			time.Date(2024, 2, 29, 10, 30, 0, 0, saoPaulo),
+			false,
+		},
+		{
+			"02-29-2024",
+			FieldType{Name: "date", Layouts: []string{"02/01/2006", "epoch"}},
+			time.Time{},
+			true,
+		},
+	}
+
+	for _, tt := range tests {
+		convert, err := newConverter(tt.fieldType)
+
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+
+		got, err := convert(tt.value)
+
+		if tt.shouldFail {
+			if err == nil {
+				t.Errorf("Expected %v to fail, but got %v", tt.value, got)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+
+		if !tt.expected.Equal(got.(time.Time)) {
+			t.Errorf("Expected %v, but got %v: %v", tt.expected, got, tt.value)
+		}
+	}
+}
+
+func TestNewConverter(t *testing.T) {
+	tests := []struct {
+		fieldType  FieldType
+		shouldFail bool
+	}{
+		{FieldType{Name: "int"}, false},
+		{FieldType{Name: "timestamp", Timezone: "Europe/Lisbon"}, false},
+		{FieldType{Name: "decimal"}, true},
+		{FieldType{Name: "int", Layouts: []string{"epoch"}}, true},
+		{FieldType{Name: "date", Timezone: "Mars/Olympus_Mons"}, true},
+	}
+
+	for _, tt := range tests {
+		_, err := newConverter(tt.fieldType)
+
+		if tt.shouldFail != (err != nil) {
+			t.Errorf("Expected %v to fail: %t, but got %v", tt.fieldType, tt.shouldFail, err)
+		}
+	}
+}
@@ -10,6 +10,9 @@ import (
 
 func main() {
 	app := &cli.App{
+		// Paths and params may have commas, so repeated flags are the only
+		// way to give several values
+		DisableSliceFlagSeparator: true,
 		Flags: []cli.Flag{
 			&cli.StringFlag{
 				Name:    "dbname",
@@ -41,7 +44,7 @@ func main() {
 			{
 				Action: internal.Insert,
 				Name:   "insert",
-				Usage:  "Insert the content from a csv file in the database",
+				Usage:  "Insert the content from a csv, json, xlsx or parquet file in the database",
 				Flags: []cli.Flag{
 					&cli.StringFlag{
 						Name:     "mapping",
@@ -55,12 +58,71 @@ func main() {
 						Usage:    "The number of lines to be inserted",
 						Required: true,
 					},
+					&cli.StringSliceFlag{
+						Name:     "input",
+						Aliases:  []string{"c", "csv"},
+						Usage:    "The path to the input file, or name=path for named sources. Can be a glob and be repeated",
+						Required: true,
+					},
+					&cli.StringFlag{
+						Name:    "format",
+						Aliases: []string{"f"},
+						Value:   "auto",
+						Usage:   "The format of the input file (auto, csv, json, ndjson, xlsx, parquet, fixed)",
+					},
 					&cli.StringFlag{
-						Name:     "csv",
-						Aliases:  []string{"c"},
-						Usage:    "The path to the csv file",
+						Name:  "sheet",
+						Usage: "The name or position of the xlsx sheet to be read",
+					},
+					&cli.StringFlag{
+						Name:  "layout",
+						Usage: "A .yml file with the columns of a fixed-width file",
+					},
+					&cli.StringFlag{
+						Name:    "encoding",
+						Aliases: []string{"e"},
+						Value:   "auto",
+						Usage:   "The encoding of the input file (auto, utf-8, utf-16le, utf-16be, latin1, windows-1252)",
+					},
+					&cli.BoolFlag{
+						Name:  "no-header",
+						Usage: "The first line of the csv file is data, not a header",
+					},
+					&cli.Int64Flag{
+						Name:  "seed",
+						Usage: "The seed of the fake data. Replaces the one in the mapping",
+					},
+					&cli.StringSliceFlag{
+						Name:  "set",
+						Usage: "A key=value param replacing ${key} in the mapping. Can be repeated",
+					},
+				},
+			},
+			{
+				Action: internal.Generate,
+				Name:   "generate",
+				Usage:  "Insert rows made from generators and fake data, without an input file",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:     "mapping",
+						Aliases:  []string{"m"},
+						Usage:    "A .yml file with the mapping",
+						Required: true,
+					},
+					&cli.IntFlag{
+						Name:     "rows",
+						Aliases:  []string{"n"},
+						Usage:    "The number of rows to be inserted for each insertion",
 						Required: true,
 					},
+					&cli.Int64Flag{
+						Name:  "seed",
+						Usage: "The seed of the fake data. Replaces the one in the mapping",
+					},
+					&cli.StringSliceFlag{
+						Name:  "set",
+						Usage: "A key=value param replacing ${key} in the mapping. Can be repeated",
+					},
 				},
 			},
 		},